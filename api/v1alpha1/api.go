@@ -16,21 +16,102 @@ const (
 	RuntimeSpecEditConfigKind = "RuntimeSpecEditConfig"
 )
 
-// Decoder implements a decoder for objects in this API group.
+// MergeStrategy controls how the resource settings of a spec are reconciled
+// with the values the kubelet already set on the container.
+type MergeStrategy string
+
+const (
+	// MergeStrategyOverride applies the spec as is, even where it loosens
+	// the limits the kubelet set for the container.
+	MergeStrategyOverride MergeStrategy = "override"
+	// MergeStrategyOnlyTighten applies only the settings that are at least
+	// as strict as the container's. Settings that would loosen a limit are
+	// skipped.
+	MergeStrategyOnlyTighten MergeStrategy = "only-tighten"
+	// MergeStrategyFailOnConflict fails container creation if a setting would
+	// loosen a limit of the container.
+	MergeStrategyFailOnConflict MergeStrategy = "fail-on-conflict"
+
+	// DefaultMergeStrategy is used when a config does not set one, so that
+	// claims never loosen limits the scheduler accounted for unless
+	// explicitly allowed.
+	DefaultMergeStrategy = MergeStrategyOnlyTighten
+)
+
+// FailurePolicy decides what happens to a container whose spec cannot be
+// applied, e.g. because it is denied by policy or cannot be translated.
+type FailurePolicy string
+
+const (
+	// FailurePolicyFailClosed fails container creation.
+	FailurePolicyFailClosed FailurePolicy = "fail-closed"
+	// FailurePolicyFailOpen creates the container without the spec.
+	FailurePolicyFailOpen FailurePolicy = "fail-open"
+)
+
+// Decoder implements a decoder for objects in this API group. It is strict:
+// fields outside of the supported subset of the runtime spec are an error.
 var Decoder runtime.Decoder
 
+// DescribeDecodeError adds a migration hint to strict decoding errors from
+// Decoder, which name every unknown field. Earlier releases of v1alpha1 took
+// Spec as an untyped object and accepted any field, so configs written for
+// them may carry fields that were silently ignored before. Other errors are
+// returned unchanged.
+func DescribeDecodeError(err error) error {
+	if !runtime.IsStrictDecodingError(err) {
+		return err
+	}
+	return fmt.Errorf("%w (spec only accepts the supported subset of the OCI runtime spec; remove these fields, which earlier releases ignored)", err)
+}
+
 // +genclient
 // +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
 
 type RuntimeSpecEditConfig struct {
 	metav1.TypeMeta `json:",inline"`
-	Spec            runtime.RawExtension `json:"spec,omitempty"`
+	MergeStrategy   MergeStrategy `json:"mergeStrategy,omitempty"`
+	// FailurePolicy overrides the node's failure policy for containers
+	// consuming this config. If the configs of a container disagree, the
+	// container fails closed.
+	FailurePolicy FailurePolicy `json:"failurePolicy,omitempty"`
+	// IOLimits throttles a block device identified by its path on the node.
+	// It is resolved and merged into io.max when the container is created.
+	// Like io.max, it may only list one device.
+	IOLimits []IOLimit `json:"ioLimits,omitempty"`
+	// Remove lists settings to strip from the container, such as defaults
+	// injected by the runtime, CDI or NRI plugins running before this one.
+	Remove *Removals `json:"remove,omitempty"`
+	// Spec is the part of the OCI runtime spec to apply. Earlier releases
+	// of v1alpha1 accepted any object here and ignored unknown fields; it
+	// is now typed and decoded strictly, which is a breaking change of the
+	// alpha API.
+	Spec *RuntimeSpec `json:"spec,omitempty"`
+}
+
+// Removals identifies container settings to remove. Removing a setting that
+// the container does not have is not an error.
+type Removals struct {
+	// Env lists the names of environment variables to remove.
+	Env []string `json:"env,omitempty"`
+	// Mounts lists the destinations of mounts to remove.
+	Mounts []string `json:"mounts,omitempty"`
+	// Devices lists the paths of device nodes to remove.
+	Devices []string `json:"devices,omitempty"`
+	// Annotations lists the keys of container annotations to remove.
+	Annotations []string `json:"annotations,omitempty"`
 }
 
 func (c *RuntimeSpecEditConfig) Normalize() error {
 	if c == nil {
 		return fmt.Errorf("config is 'nil'")
 	}
+	if c.MergeStrategy == "" {
+		c.MergeStrategy = DefaultMergeStrategy
+	}
+	if c.Spec == nil {
+		c.Spec = &RuntimeSpec{}
+	}
 	return nil
 }
 
@@ -0,0 +1,79 @@
+package v1alpha1
+
+import (
+	"fmt"
+	"strings"
+)
+
+// IOLimit throttles a block device identified by its path on the node. Device
+// numbers differ from node to node, so the NRI plugin resolves Device to its
+// <major>:<minor> number when the container is created and applies the limits
+// as io.max. Like io.max, IOLimits may only throttle one device.
+type IOLimit struct {
+	// Device is the absolute path of the block device on the node, e.g.
+	// /dev/nvme0n1.
+	Device string `json:"device"`
+	// Rbps limits reads in bytes per second.
+	Rbps *uint64 `json:"rbps,omitempty"`
+	// Wbps limits writes in bytes per second.
+	Wbps *uint64 `json:"wbps,omitempty"`
+	// Riops limits read operations per second.
+	Riops *uint64 `json:"riops,omitempty"`
+	// Wiops limits write operations per second.
+	Wiops *uint64 `json:"wiops,omitempty"`
+}
+
+// limits returns the io.max key=value pairs of l in the canonical order.
+func (l *IOLimit) limits() []string {
+	var kvs []string
+	for _, limit := range []struct {
+		key   string
+		value *uint64
+	}{
+		{"rbps", l.Rbps},
+		{"wbps", l.Wbps},
+		{"riops", l.Riops},
+		{"wiops", l.Wiops},
+	} {
+		if limit.value != nil {
+			kvs = append(kvs, fmt.Sprintf("%s=%d", limit.key, *limit.value))
+		}
+	}
+	return kvs
+}
+
+// SpecForPolicy returns the spec as it has to be checked against policies.
+// IOLimits cannot be resolved to device numbers before the container is
+// created, so they are added as io.max lines keyed by device path. Policies
+// only look at the limit values of io.max and thereby treat them like any
+// other io.max limit.
+func (c *RuntimeSpecEditConfig) SpecForPolicy() *RuntimeSpec {
+	if len(c.IOLimits) == 0 {
+		return c.Spec
+	}
+
+	spec := c.Spec.DeepCopy()
+	if spec == nil {
+		spec = &RuntimeSpec{}
+	}
+	if spec.Linux == nil {
+		spec.Linux = &Linux{}
+	}
+	if spec.Linux.Resources == nil {
+		spec.Linux.Resources = &LinuxResources{}
+	}
+	if spec.Linux.Resources.Unified == nil {
+		spec.Linux.Resources.Unified = make(map[string]string)
+	}
+
+	var lines []string
+	if existing := spec.Linux.Resources.Unified["io.max"]; existing != "" {
+		lines = append(lines, existing)
+	}
+	for i := range c.IOLimits {
+		lines = append(lines, strings.Join(append([]string{c.IOLimits[i].Device}, c.IOLimits[i].limits()...), " "))
+	}
+	spec.Linux.Resources.Unified["io.max"] = strings.Join(lines, "\n")
+
+	return spec
+}
@@ -0,0 +1,355 @@
+package v1alpha1
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"slices"
+)
+
+// The types in this file are a typed subset of the OCI runtime spec
+// (github.com/opencontainers/runtime-spec/specs-go). Only the fields that the
+// NRI plugin knows how to translate into a container adjustment are present,
+// and the JSON field names match the upstream spec exactly. Because the API
+// Decoder is strict, any field outside of this subset (or a typo such as
+// "unifed") is rejected when the claim is decoded instead of being silently
+// dropped when the container is created.
+//
+// A few process fields that NRI cannot apply (capabilities, noNewPrivileges,
+// apparmorProfile, selinuxLabel) are declared anyway so that Validate can
+// reject them with an explanation rather than a bare "unknown field".
+
+// RuntimeSpec is the subset of the OCI runtime spec that can be applied to a
+// container through a RuntimeSpecEditConfig.
+type RuntimeSpec struct {
+	// Process configures the container process.
+	Process *Process `json:"process,omitempty"`
+	// Mounts configures additional mounts (on top of Root).
+	Mounts []Mount `json:"mounts,omitempty"`
+	// Hooks configures callbacks for container lifecycle events.
+	Hooks *Hooks `json:"hooks,omitempty"`
+	// Linux is platform-specific configuration for Linux based containers.
+	Linux *Linux `json:"linux,omitempty"`
+	// Annotations request container settings the OCI runtime spec has no
+	// field for. Only the annotations in SupportedAnnotations may be set; they
+	// are not added to the container.
+	Annotations map[string]string `json:"annotations,omitempty"`
+}
+
+const (
+	// BlockIOClassAnnotation is the spec annotation selecting the blockio
+	// class of the container. Classes are defined in the runtime's blockio
+	// configuration.
+	BlockIOClassAnnotation = "runtime-spec.io/blockio-class"
+
+	// SeccompProfileAnnotation is the spec annotation selecting a seccomp
+	// profile on the node, given as a path relative to the NRI plugin's
+	// seccomp profile root (the kubelet's seccomp directory by default). The
+	// profile must be a JSON encoded OCI LinuxSeccomp. It cannot be combined
+	// with linux.seccomp.
+	SeccompProfileAnnotation = "runtime-spec.io/seccomp-profile"
+)
+
+// SupportedAnnotations are the spec annotations that may be set.
+var SupportedAnnotations = []string{BlockIOClassAnnotation, SeccompProfileAnnotation}
+
+// Process contains information to start a specific application inside the container.
+type Process struct {
+	// Env populates the process environment for the process.
+	Env []string `json:"env,omitempty"`
+	// Rlimits specifies rlimit options to apply to the process.
+	Rlimits []POSIXRlimit `json:"rlimits,omitempty"`
+	// OOMScoreAdj adjusts the oom-killer score in [pid]/oom_score_adj for the process.
+	OOMScoreAdj *int `json:"oomScoreAdj,omitempty"`
+	// Capabilities are Linux capabilities that are kept for the process.
+	// Not supported by NRI.
+	Capabilities *LinuxCapabilities `json:"capabilities,omitempty"`
+	// NoNewPrivileges controls whether additional privileges could be gained by processes in the container.
+	// Not supported by NRI.
+	NoNewPrivileges bool `json:"noNewPrivileges,omitempty"`
+	// ApparmorProfile specifies the apparmor profile for the container.
+	// Not supported by NRI.
+	ApparmorProfile string `json:"apparmorProfile,omitempty"`
+	// SelinuxLabel specifies the selinux context that the container process is run as.
+	// Not supported by NRI.
+	SelinuxLabel string `json:"selinuxLabel,omitempty"`
+}
+
+// LinuxCapabilities specifies the list of allowed capabilities that are kept for a process.
+type LinuxCapabilities struct {
+	// Bounding is the set of capabilities checked by the kernel.
+	Bounding []string `json:"bounding,omitempty"`
+	// Effective is the set of capabilities checked by the kernel.
+	Effective []string `json:"effective,omitempty"`
+	// Inheritable is the capabilities preserved across execve.
+	Inheritable []string `json:"inheritable,omitempty"`
+	// Permitted is the limiting superset for effective capabilities.
+	Permitted []string `json:"permitted,omitempty"`
+	// Ambient is the ambient set of capabilities that are kept.
+	Ambient []string `json:"ambient,omitempty"`
+}
+
+// POSIXRlimit type and restrictions
+type POSIXRlimit struct {
+	// Type of the rlimit to set
+	Type string `json:"type"`
+	// Hard is the hard limit for the specified type
+	Hard uint64 `json:"hard"`
+	// Soft is the soft limit for the specified type
+	Soft uint64 `json:"soft"`
+}
+
+// Mount specifies a mount for a container.
+type Mount struct {
+	// Destination is the absolute path where the mount will be placed in the container.
+	Destination string `json:"destination"`
+	// Type specifies the mount kind.
+	Type string `json:"type,omitempty"`
+	// Source specifies the source path of the mount.
+	Source string `json:"source,omitempty"`
+	// Options are fstab style mount options.
+	Options []string `json:"options,omitempty"`
+}
+
+// IsBind reports whether m bind mounts Source from the host.
+func (m *Mount) IsBind() bool {
+	return m.Type == "bind" || slices.Contains(m.Options, "bind") || slices.Contains(m.Options, "rbind")
+}
+
+// Hook specifies a command that is run at a particular event in the lifecycle of a container.
+type Hook struct {
+	Path    string   `json:"path"`
+	Args    []string `json:"args,omitempty"`
+	Env     []string `json:"env,omitempty"`
+	Timeout *int     `json:"timeout,omitempty"`
+}
+
+// Hooks specifies a command that is run in the container at a particular event
+// in the lifecycle of a container.
+type Hooks struct {
+	Prestart        []Hook `json:"prestart,omitempty"`
+	CreateRuntime   []Hook `json:"createRuntime,omitempty"`
+	CreateContainer []Hook `json:"createContainer,omitempty"`
+	StartContainer  []Hook `json:"startContainer,omitempty"`
+	Poststart       []Hook `json:"poststart,omitempty"`
+	Poststop        []Hook `json:"poststop,omitempty"`
+}
+
+// Linux contains platform-specific configuration for Linux based containers.
+type Linux struct {
+	// Resources contain cgroup information for handling resource constraints
+	// for the container.
+	Resources *LinuxResources `json:"resources,omitempty"`
+	// Devices are a list of device nodes that are created for the container.
+	Devices []LinuxDevice `json:"devices,omitempty"`
+	// Namespaces the container joins or creates instead of the ones it would
+	// otherwise use. Only network namespaces under NetNSDir and new time
+	// namespaces are supported.
+	Namespaces []LinuxNamespace `json:"namespaces,omitempty"`
+	// Sysctl are a set of key value pairs that are set for the container on start.
+	Sysctl map[string]string `json:"sysctl,omitempty"`
+	// IntelRdt contains Intel Resource Director Technology (RDT) information
+	// for handling resource constraints and monitoring metrics.
+	IntelRdt *LinuxIntelRdt `json:"intelRdt,omitempty"`
+	// Seccomp specifies the seccomp security settings for the container. It
+	// replaces the profile the container would otherwise run with.
+	Seccomp *LinuxSeccomp `json:"seccomp,omitempty"`
+}
+
+// NetNSDir is the directory holding named network namespaces, as created by
+// "ip netns add".
+const NetNSDir = "/var/run/netns"
+
+// LinuxNamespace is the configuration for a Linux namespace.
+type LinuxNamespace struct {
+	// Type is the type of namespace, e.g. "network" or "time".
+	Type string `json:"type"`
+	// Path is a path to an existing namespace to join. If empty, a new
+	// namespace is created.
+	Path string `json:"path,omitempty"`
+}
+
+// LinuxIntelRdt has container runtime resource constraints for Intel RDT CAT
+// and MBA features.
+type LinuxIntelRdt struct {
+	// ClosID is the identity for RDT Class of Service. It is applied as the
+	// RDT class of the container, which the runtime maps to a resctrl group.
+	ClosID string `json:"closID,omitempty"`
+}
+
+// LinuxSeccomp represents syscall restrictions.
+type LinuxSeccomp struct {
+	DefaultAction    string         `json:"defaultAction"`
+	DefaultErrnoRet  *uint          `json:"defaultErrnoRet,omitempty"`
+	Architectures    []string       `json:"architectures,omitempty"`
+	Flags            []string       `json:"flags,omitempty"`
+	ListenerPath     string         `json:"listenerPath,omitempty"`
+	ListenerMetadata string         `json:"listenerMetadata,omitempty"`
+	Syscalls         []LinuxSyscall `json:"syscalls,omitempty"`
+}
+
+// LinuxSyscall is used to match a syscall in seccomp.
+type LinuxSyscall struct {
+	Names    []string          `json:"names"`
+	Action   string            `json:"action"`
+	ErrnoRet *uint             `json:"errnoRet,omitempty"`
+	Args     []LinuxSeccompArg `json:"args,omitempty"`
+}
+
+// LinuxSeccompArg used for matching specific syscall arguments in seccomp.
+type LinuxSeccompArg struct {
+	Index    uint   `json:"index"`
+	Value    uint64 `json:"value"`
+	ValueTwo uint64 `json:"valueTwo,omitempty"`
+	Op       string `json:"op"`
+}
+
+// LinuxResources has container runtime resource constraints.
+type LinuxResources struct {
+	// Memory restriction configuration.
+	Memory *LinuxMemory `json:"memory,omitempty"`
+	// CPU resource restriction configuration.
+	CPU *LinuxCPU `json:"cpu,omitempty"`
+	// BlockIO restriction configuration. It is translated to the io.weight
+	// and io.max unified parameters.
+	BlockIO *LinuxBlockIO `json:"blockIO,omitempty"`
+	// Task resource restriction configuration.
+	Pids *LinuxPids `json:"pids,omitempty"`
+	// Hugetlb limits (in bytes). Default to reservation limits if supported.
+	HugepageLimits []LinuxHugepageLimit `json:"hugepageLimits,omitempty"`
+	// Unified resources.
+	Unified map[string]string `json:"unified,omitempty"`
+}
+
+// LinuxMemory for Linux cgroup 'memory' resource management.
+type LinuxMemory struct {
+	// Memory limit (in bytes).
+	Limit *int64 `json:"limit,omitempty"`
+	// Memory reservation or soft_limit (in bytes).
+	Reservation *int64 `json:"reservation,omitempty"`
+	// Total memory limit (memory + swap).
+	Swap *int64 `json:"swap,omitempty"`
+	// Kernel memory limit (in bytes). Deprecated in the kernel and ignored by
+	// most runtimes.
+	Kernel *int64 `json:"kernel,omitempty"`
+	// Kernel memory limit for tcp (in bytes).
+	KernelTCP *int64 `json:"kernelTCP,omitempty"`
+	// How aggressive the kernel will swap memory pages.
+	Swappiness *uint64 `json:"swappiness,omitempty"`
+	// DisableOOMKiller disables the OOM killer for out of memory conditions.
+	DisableOOMKiller *bool `json:"disableOOMKiller,omitempty"`
+	// Enables hierarchical memory accounting.
+	UseHierarchy *bool `json:"useHierarchy,omitempty"`
+	// CheckBeforeUpdate makes the runtime check the current usage before
+	// lowering the limit. NRI cannot express it, so setting it is rejected.
+	CheckBeforeUpdate *bool `json:"checkBeforeUpdate,omitempty"`
+}
+
+// LinuxCPU for Linux cgroup 'cpu' resource management.
+type LinuxCPU struct {
+	// CPU shares (relative weight (ratio) vs. other cgroups with cpu shares).
+	Shares *uint64 `json:"shares,omitempty"`
+	// CPU hardcap limit (in usecs). Allowed cpu time in a given period.
+	Quota *int64 `json:"quota,omitempty"`
+	// CPU hardcap burst limit (in usecs). Allowed accumulated cpu time
+	// additionally for burst in a given period. It is translated to the
+	// cpu.max.burst unified parameter.
+	Burst *uint64 `json:"burst,omitempty"`
+	// CPU period to be used for hardcapping (in usecs).
+	Period *uint64 `json:"period,omitempty"`
+	// How much time realtime scheduling may use (in usecs).
+	RealtimeRuntime *int64 `json:"realtimeRuntime,omitempty"`
+	// CPU period to be used for realtime scheduling (in usecs).
+	RealtimePeriod *uint64 `json:"realtimePeriod,omitempty"`
+	// CPUs to use within the cpuset. Default is to use any CPU available.
+	Cpus string `json:"cpus,omitempty"`
+	// List of memory nodes in the cpuset. Default is to use any available memory node.
+	Mems string `json:"mems,omitempty"`
+	// Idle sets the cgroup to SCHED_IDLE when 1. It is translated to the
+	// cpu.idle unified parameter.
+	Idle *int64 `json:"idle,omitempty"`
+}
+
+// LinuxPids for Linux cgroup 'pids' resource management (Linux 4.3).
+type LinuxPids struct {
+	// Maximum number of PIDs. A value of -1 removes the limit.
+	Limit int64 `json:"limit"`
+}
+
+// LinuxBlockIO for Linux cgroup 'blkio' resource management.
+type LinuxBlockIO struct {
+	// Specifies per cgroup weight
+	Weight *uint16 `json:"weight,omitempty"`
+	// Weight per cgroup per device, can override Weight
+	WeightDevice []LinuxWeightDevice `json:"weightDevice,omitempty"`
+	// IO read rate limit per cgroup per device, bytes per second
+	ThrottleReadBpsDevice []LinuxThrottleDevice `json:"throttleReadBpsDevice,omitempty"`
+	// IO write rate limit per cgroup per device, bytes per second
+	ThrottleWriteBpsDevice []LinuxThrottleDevice `json:"throttleWriteBpsDevice,omitempty"`
+	// IO read rate limit per cgroup per device, IO per second
+	ThrottleReadIOPSDevice []LinuxThrottleDevice `json:"throttleReadIOPSDevice,omitempty"`
+	// IO write rate limit per cgroup per device, IO per second
+	ThrottleWriteIOPSDevice []LinuxThrottleDevice `json:"throttleWriteIOPSDevice,omitempty"`
+}
+
+// LinuxWeightDevice struct holds a `major:minor weight` pair for weightDevice
+type LinuxWeightDevice struct {
+	// Major is the device's major number.
+	Major int64 `json:"major"`
+	// Minor is the device's minor number.
+	Minor int64 `json:"minor"`
+	// Weight is the bandwidth rate for the device.
+	Weight *uint16 `json:"weight,omitempty"`
+}
+
+// LinuxThrottleDevice struct holds a `major:minor rate_per_second` pair
+type LinuxThrottleDevice struct {
+	// Major is the device's major number.
+	Major int64 `json:"major"`
+	// Minor is the device's minor number.
+	Minor int64 `json:"minor"`
+	// Rate is the IO rate limit per cgroup per device
+	Rate uint64 `json:"rate"`
+}
+
+// LinuxHugepageLimit structure corresponds to limiting kernel hugepages.
+type LinuxHugepageLimit struct {
+	// Pagesize is the hugepage size.
+	Pagesize string `json:"pageSize"`
+	// Limit is the limit of "hugepagesize" hugetlb usage.
+	Limit uint64 `json:"limit"`
+}
+
+// LinuxDevice represents the mknod information for a Linux special device file.
+type LinuxDevice struct {
+	// Path to the device.
+	Path string `json:"path"`
+	// Device type, block, char, etc.
+	Type string `json:"type"`
+	// Major is the device's major number.
+	Major int64 `json:"major"`
+	// Minor is the device's minor number.
+	Minor int64 `json:"minor"`
+	// FileMode permission bits for the device.
+	FileMode *uint32 `json:"fileMode,omitempty"`
+	// UID of the device.
+	UID *uint32 `json:"uid,omitempty"`
+	// Gid of the device.
+	GID *uint32 `json:"gid,omitempty"`
+}
+
+// ParseRuntimeSpec decodes a JSON encoded RuntimeSpec, rejecting any fields
+// that are not part of the supported subset of the OCI runtime spec.
+func ParseRuntimeSpec(data []byte) (*RuntimeSpec, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+
+	var spec RuntimeSpec
+	if err := decoder.Decode(&spec); err != nil {
+		return nil, fmt.Errorf("error decoding runtime spec: %w", err)
+	}
+	if decoder.More() {
+		return nil, fmt.Errorf("error decoding runtime spec: unexpected data after spec")
+	}
+	return &spec, nil
+}
@@ -0,0 +1,109 @@
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	RuntimeSpecPolicyKind     = "RuntimeSpecPolicy"
+	RuntimeSpecPolicyResource = "runtimespecpolicies"
+)
+
+// +genclient
+// +genclient:nonNamespaced
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// RuntimeSpecPolicy grants the namespaces it selects permission to set parts
+// of the runtime spec through RuntimeSpecEditConfigs. A claim is admitted if at
+// least one policy selecting its namespace allows its spec.
+type RuntimeSpecPolicy struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec RuntimeSpecPolicySpec `json:"spec"`
+}
+
+// RuntimeSpecPolicySpec describes which namespaces a policy applies to and
+// what claims in those namespaces may set.
+type RuntimeSpecPolicySpec struct {
+	// NamespaceSelector selects the namespaces this policy applies to. An
+	// empty selector selects all namespaces.
+	NamespaceSelector *metav1.LabelSelector `json:"namespaceSelector,omitempty"`
+
+	RuntimeSpecPolicyRules `json:",inline"`
+}
+
+// RuntimeSpecPolicyRules restricts which parts of a RuntimeSpec may be set.
+//
+// Field paths use the JSON field names of the spec joined by dots, e.g.
+// "linux.resources.unified" or "hooks.prestart". A path matches itself and
+// everything below it. Empty lists place no restriction, except for the rules
+// on removals, namespaces, seccomp profiles and merge strategies, which loosen
+// the container's isolation and therefore deny by default.
+type RuntimeSpecPolicyRules struct {
+	// AllowedFields lists the spec paths a claim may set. When non-empty,
+	// setting any field not covered by one of these paths is denied.
+	// Removals are checked as "remove.env", "remove.mounts",
+	// "remove.devices" and "remove.annotations" and are denied unless
+	// listed here. Paths below "remove" do not restrict the spec.
+	AllowedFields []string `json:"allowedFields,omitempty"`
+	// DeniedFields lists the spec paths a claim may never set. Denials take
+	// precedence over AllowedFields.
+	DeniedFields []string `json:"deniedFields,omitempty"`
+	// AllowedHookPaths lists the host binaries that may be used as hooks.
+	AllowedHookPaths []string `json:"allowedHookPaths,omitempty"`
+	// AllowedMountSources lists the host directories (and everything below
+	// them) that may be used as mount sources.
+	AllowedMountSources []string `json:"allowedMountSources,omitempty"`
+	// AllowedSysctls lists the sysctls a claim may set in addition to the
+	// built-in list of safe namespaced sysctls. An entry ending in "*" allows
+	// every sysctl with that prefix, e.g. "net.ipv4.tcp_*".
+	AllowedSysctls []string `json:"allowedSysctls,omitempty"`
+	// AllowedSeccompProfiles lists the node seccomp profiles a claim may
+	// reference through the runtime-spec.io/seccomp-profile annotation. An
+	// entry ending in "*" allows every profile with that prefix, e.g.
+	// "profiles/*". A profile may allow any syscall, so none is allowed by
+	// default.
+	AllowedSeccompProfiles []string `json:"allowedSeccompProfiles,omitempty"`
+	// AllowInlineSeccompProfiles allows claims to set an inline profile in
+	// linux.seccomp. Inline profiles cannot be restricted by name, so they
+	// are denied unless this is set.
+	AllowInlineSeccompProfiles bool `json:"allowInlineSeccompProfiles,omitempty"`
+	// AllowedNamespaces lists the namespace types, e.g. "network" or "time",
+	// that a claim may set in linux.namespaces. Moving a container into
+	// another namespace is privileged, so no type is allowed by default.
+	AllowedNamespaces []string `json:"allowedNamespaces,omitempty"`
+	// AllowedMergeStrategies lists the merge strategies a config may use in
+	// addition to "only-tighten" and "fail-on-conflict", which never loosen
+	// the container's limits. "override" lets a claim loosen the limits the
+	// scheduler accounted for, so it is denied unless listed.
+	AllowedMergeStrategies []MergeStrategy `json:"allowedMergeStrategies,omitempty"`
+	// Unified lists the unified cgroup keys a claim may set, optionally with
+	// a range of accepted values.
+	Unified []UnifiedRule `json:"unified,omitempty"`
+}
+
+// UnifiedRule allows a single unified cgroup key.
+type UnifiedRule struct {
+	// Key is the cgroup v2 interface file, e.g. "memory.high".
+	Key string `json:"key"`
+	// Min is the smallest accepted value.
+	Min *uint64 `json:"min,omitempty"`
+	// Max is the largest accepted value. When set, the literal "max"
+	// (unlimited) is rejected.
+	//
+	// Min and Max apply to keys holding a single number, such as
+	// memory.high or pids.max, to each limit of io.max, each weight of
+	// io.weight and the quota of cpu.max. They cannot be set for other keys.
+	Max *uint64 `json:"max,omitempty"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// RuntimeSpecPolicyList is a list of RuntimeSpecPolicy objects.
+type RuntimeSpecPolicyList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+
+	Items []RuntimeSpecPolicy `json:"items"`
+}
@@ -0,0 +1,213 @@
+package v1alpha1
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// IOWeightDefault is the io.weight entry applying to all devices.
+const IOWeightDefault = "default"
+
+// EffectiveUnified returns the unified cgroup v2 parameters of r with the
+// settings that have no dedicated NRI field translated to unified keys: BlockIO
+// to io.weight and io.max, and CPU burst and idle to cpu.max.burst and
+// cpu.idle. Translated entries are merged with explicit values in Unified;
+// setting the same key or device limit to different values in both places is
+// an error.
+//
+// Weights use the OCI (cgroup v1) range of 10-1000 and are converted to the
+// cgroup v2 range of 1-10000 the same way runc does. A throttle rate of 0
+// removes the limit, as it does on cgroup v1.
+//
+// Runtimes write each unified value at once and the kernel only accepts one
+// device per write to io.weight and io.max, so ValidateRuntimeSpec rejects
+// resources whose effective io.weight or io.max name more than one device.
+func (r *LinuxResources) EffectiveUnified() (map[string]string, error) {
+	if r == nil {
+		return nil, nil
+	}
+	cpuUnified := r.CPU != nil && (r.CPU.Burst != nil || r.CPU.Idle != nil)
+	if r.BlockIO == nil && !cpuUnified {
+		return r.Unified, nil
+	}
+
+	unified := maps.Clone(r.Unified)
+	if unified == nil {
+		unified = make(map[string]string)
+	}
+
+	if cpuUnified {
+		setCPU := func(key string, value int64) error {
+			v := strconv.FormatInt(value, 10)
+			if existing, ok := unified[key]; ok && strings.TrimSpace(existing) != v {
+				return fmt.Errorf("%s is %s in unified but cpu sets %s", key, existing, v)
+			}
+			unified[key] = v
+			return nil
+		}
+		if r.CPU.Burst != nil {
+			if err := setCPU("cpu.max.burst", int64(*r.CPU.Burst)); err != nil {
+				return nil, err
+			}
+		}
+		if r.CPU.Idle != nil {
+			if err := setCPU("cpu.idle", *r.CPU.Idle); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if r.BlockIO != nil {
+		if err := r.BlockIO.addUnified(unified); err != nil {
+			return nil, err
+		}
+	}
+
+	return unified, nil
+}
+
+// addUnified merges the io.weight and io.max entries for b into unified.
+func (b *LinuxBlockIO) addUnified(unified map[string]string) error {
+	weights := parseIOWeight(unified["io.weight"])
+	setWeight := func(device string, weight uint16) error {
+		value := strconv.FormatUint(convertBlkIOToIOWeight(weight), 10)
+		if existing, ok := weights[device]; ok && existing != value {
+			return fmt.Errorf("io.weight for %s is %s in unified but blockIO sets %s", device, existing, value)
+		}
+		weights[device] = value
+		return nil
+	}
+	if b.Weight != nil {
+		if err := setWeight(IOWeightDefault, *b.Weight); err != nil {
+			return err
+		}
+	}
+	for _, wd := range b.WeightDevice {
+		if wd.Weight == nil {
+			continue
+		}
+		if err := setWeight(fmt.Sprintf("%d:%d", wd.Major, wd.Minor), *wd.Weight); err != nil {
+			return err
+		}
+	}
+	if len(weights) > 0 {
+		unified["io.weight"] = formatIOWeight(weights)
+	}
+
+	limits := parseIOMax(unified["io.max"])
+	throttles := []struct {
+		key     string
+		devices []LinuxThrottleDevice
+	}{
+		{"rbps", b.ThrottleReadBpsDevice},
+		{"wbps", b.ThrottleWriteBpsDevice},
+		{"riops", b.ThrottleReadIOPSDevice},
+		{"wiops", b.ThrottleWriteIOPSDevice},
+	}
+	for _, t := range throttles {
+		for _, td := range t.devices {
+			device := fmt.Sprintf("%d:%d", td.Major, td.Minor)
+			value := "max"
+			if td.Rate > 0 {
+				value = strconv.FormatUint(td.Rate, 10)
+			}
+			if limits[device] == nil {
+				limits[device] = make(map[string]string)
+			}
+			if existing, ok := limits[device][t.key]; ok && existing != value {
+				return fmt.Errorf("io.max %s for %s is %s in unified but blockIO sets %s", t.key, device, existing, value)
+			}
+			limits[device][t.key] = value
+		}
+	}
+	if len(limits) > 0 {
+		unified["io.max"] = formatIOMax(limits)
+	}
+
+	return nil
+}
+
+// convertBlkIOToIOWeight converts a cgroup v1 blkio weight (10-1000) to a
+// cgroup v2 io weight (1-10000).
+func convertBlkIOToIOWeight(weight uint16) uint64 {
+	if weight == 0 {
+		return 0
+	}
+	return 1 + (uint64(weight)-10)*9999/990
+}
+
+// CPUSharesToWeight converts cpu shares (2-262144) to a cgroup v2 weight
+// (1-10000) the same way runc does.
+func CPUSharesToWeight(shares uint64) uint64 {
+	if shares < 2 {
+		shares = 2
+	}
+	return 1 + (shares-2)*9999/262142
+}
+
+// parseIOWeight parses io.weight lines of the form "default <weight>",
+// "<major>:<minor> <weight>" or a bare "<weight>" into a map keyed by device.
+func parseIOWeight(value string) map[string]string {
+	weights := make(map[string]string)
+	for _, line := range strings.Split(value, "\n") {
+		switch fields := strings.Fields(line); len(fields) {
+		case 1:
+			weights[IOWeightDefault] = fields[0]
+		case 2:
+			weights[fields[0]] = fields[1]
+		}
+	}
+	return weights
+}
+
+// formatIOWeight formats weights with the default first and devices sorted.
+func formatIOWeight(weights map[string]string) string {
+	var lines []string
+	if w, ok := weights[IOWeightDefault]; ok {
+		lines = append(lines, IOWeightDefault+" "+w)
+	}
+	for _, device := range slices.Sorted(maps.Keys(weights)) {
+		if device != IOWeightDefault {
+			lines = append(lines, device+" "+weights[device])
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// parseIOMax parses io.max lines into a map keyed by device and limit key.
+func parseIOMax(value string) map[string]map[string]string {
+	limits := make(map[string]map[string]string)
+	for _, line := range strings.Split(value, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+		if limits[fields[0]] == nil {
+			limits[fields[0]] = make(map[string]string)
+		}
+		for _, kv := range fields[1:] {
+			if k, v, ok := strings.Cut(kv, "="); ok {
+				limits[fields[0]][k] = v
+			}
+		}
+	}
+	return limits
+}
+
+// formatIOMax formats limits with one line per device, sorted by device.
+func formatIOMax(limits map[string]map[string]string) string {
+	var lines []string
+	for _, device := range slices.Sorted(maps.Keys(limits)) {
+		line := []string{device}
+		for _, key := range []string{"rbps", "wbps", "riops", "wiops"} {
+			if v, ok := limits[device][key]; ok {
+				line = append(line, key+"="+v)
+			}
+		}
+		lines = append(lines, strings.Join(line, " "))
+	}
+	return strings.Join(lines, "\n")
+}
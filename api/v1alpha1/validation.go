@@ -0,0 +1,712 @@
+package v1alpha1
+
+import (
+	"fmt"
+	"maps"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/util/validation/field"
+)
+
+var (
+	// unifiedKeyRegexp matches cgroup v2 interface files, which are always of
+	// the form <controller>.<file>. Files may contain upper case letters, as
+	// in hugetlb.2MB.max.
+	unifiedKeyRegexp = regexp.MustCompile(`^[a-z0-9_]+\.[a-zA-Z0-9_.]+$`)
+	// sysctlRegexp matches a sysctl name in dotted notation.
+	sysctlRegexp = regexp.MustCompile(`^[a-z0-9_]+(\.[a-z0-9_-]+)+$`)
+	// blockDeviceRegexp matches a <major>:<minor> block device number.
+	blockDeviceRegexp = regexp.MustCompile(`^[0-9]+:[0-9]+$`)
+)
+
+// namespacedSysctlPrefixes are the prefixes of the sysctls that are isolated
+// by a Linux namespace and can therefore be set for a single container.
+var namespacedSysctlPrefixes = []string{
+	"kernel.shm",
+	"kernel.msg",
+	"kernel.sem",
+	"fs.mqueue.",
+	"net.",
+}
+
+// unifiedLimitKeys are the unified keys whose value is a single byte or
+// count limit, or the literal "max".
+var unifiedLimitKeys = map[string]bool{
+	"pids.max":        true,
+	"memory.high":     true,
+	"memory.max":      true,
+	"memory.low":      true,
+	"memory.min":      true,
+	"memory.swap.max": true,
+}
+
+// seccompActions are the actions a seccomp rule can take.
+var seccompActions = []string{
+	"SCMP_ACT_KILL",
+	"SCMP_ACT_KILL_PROCESS",
+	"SCMP_ACT_KILL_THREAD",
+	"SCMP_ACT_TRAP",
+	"SCMP_ACT_ERRNO",
+	"SCMP_ACT_TRACE",
+	"SCMP_ACT_ALLOW",
+	"SCMP_ACT_LOG",
+	"SCMP_ACT_NOTIFY",
+}
+
+// seccompOperators are the comparisons a seccomp rule can apply to arguments.
+var seccompOperators = []string{
+	"SCMP_CMP_NE",
+	"SCMP_CMP_LT",
+	"SCMP_CMP_LE",
+	"SCMP_CMP_EQ",
+	"SCMP_CMP_GE",
+	"SCMP_CMP_GT",
+	"SCMP_CMP_MASKED_EQ",
+}
+
+// rlimitTypes are the rlimits that can be set on a Linux process.
+var rlimitTypes = []string{
+	"RLIMIT_AS",
+	"RLIMIT_CORE",
+	"RLIMIT_CPU",
+	"RLIMIT_DATA",
+	"RLIMIT_FSIZE",
+	"RLIMIT_LOCKS",
+	"RLIMIT_MEMLOCK",
+	"RLIMIT_MSGQUEUE",
+	"RLIMIT_NICE",
+	"RLIMIT_NOFILE",
+	"RLIMIT_NPROC",
+	"RLIMIT_RSS",
+	"RLIMIT_RTPRIO",
+	"RLIMIT_RTTIME",
+	"RLIMIT_SIGPENDING",
+	"RLIMIT_STACK",
+}
+
+// oneIODeviceMsg explains why io.max and io.weight may only set one device.
+// Runtimes write each unified value with a single write(2), and the kernel
+// only parses one device per write to these files.
+const oneIODeviceMsg = "may only set one device, the kernel accepts one device per write and runtimes write each value at once"
+
+// ioMaxKeys are the keys allowed in an io.max line.
+var ioMaxKeys = map[string]bool{
+	"rbps":  true,
+	"wbps":  true,
+	"riops": true,
+	"wiops": true,
+}
+
+// Validate ensures that the config only requests changes the NRI plugin is
+// able to apply. Fields outside of the supported subset of the OCI runtime
+// spec are already rejected by the strict Decoder; this catches values that
+// decode fine but would fail (or be ignored) at container creation.
+func (c *RuntimeSpecEditConfig) Validate() error {
+	var allErrs field.ErrorList
+	switch c.MergeStrategy {
+	case "", MergeStrategyOverride, MergeStrategyOnlyTighten, MergeStrategyFailOnConflict:
+	default:
+		allErrs = append(allErrs, field.NotSupported(field.NewPath("mergeStrategy"), c.MergeStrategy, []MergeStrategy{MergeStrategyOverride, MergeStrategyOnlyTighten, MergeStrategyFailOnConflict}))
+	}
+	switch c.FailurePolicy {
+	case "", FailurePolicyFailClosed, FailurePolicyFailOpen:
+	default:
+		allErrs = append(allErrs, field.NotSupported(field.NewPath("failurePolicy"), c.FailurePolicy, []FailurePolicy{FailurePolicyFailClosed, FailurePolicyFailOpen}))
+	}
+	allErrs = append(allErrs, validateIOLimits(c.IOLimits, field.NewPath("ioLimits"))...)
+	allErrs = append(allErrs, ValidateRemovals(c.Remove, c.Spec, field.NewPath("remove"))...)
+	allErrs = append(allErrs, ValidateRuntimeSpec(c.Spec, field.NewPath("spec"))...)
+	return allErrs.ToAggregate()
+}
+
+func validateIOLimits(limits []IOLimit, fldPath *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+	if len(limits) > 1 {
+		allErrs = append(allErrs, field.TooMany(fldPath, len(limits), 1))
+	}
+	seen := make(map[string]bool)
+	for i := range limits {
+		l := &limits[i]
+		idxPath := fldPath.Index(i)
+		allErrs = append(allErrs, validateAbsolutePath(l.Device, idxPath.Child("device"))...)
+		if seen[filepath.Clean(l.Device)] {
+			allErrs = append(allErrs, field.Duplicate(idxPath.Child("device"), l.Device))
+		}
+		seen[filepath.Clean(l.Device)] = true
+		if len(l.limits()) == 0 {
+			allErrs = append(allErrs, field.Required(idxPath, "at least one of rbps, wbps, riops or wiops must be set"))
+		}
+		for _, v := range []struct {
+			name  string
+			value *uint64
+		}{
+			{"rbps", l.Rbps},
+			{"wbps", l.Wbps},
+			{"riops", l.Riops},
+			{"wiops", l.Wiops},
+		} {
+			if v.value != nil && *v.value == 0 {
+				allErrs = append(allErrs, field.Invalid(idxPath.Child(v.name), *v.value, "must be greater than zero"))
+			}
+		}
+	}
+	return allErrs
+}
+
+// ValidateRuntimeSpec validates a RuntimeSpec rooted at fldPath.
+func ValidateRuntimeSpec(spec *RuntimeSpec, fldPath *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+	if spec == nil {
+		return allErrs
+	}
+
+	if spec.Process != nil {
+		allErrs = append(allErrs, validateProcess(spec.Process, fldPath.Child("process"))...)
+	}
+	for i, m := range spec.Mounts {
+		idxPath := fldPath.Child("mounts").Index(i)
+		allErrs = append(allErrs, validateAbsolutePath(m.Destination, idxPath.Child("destination"))...)
+		// Relative sources would be resolved against the bundle directory
+		// by the runtime and escape the policy's mount source check.
+		if m.IsBind() {
+			allErrs = append(allErrs, validateAbsolutePath(m.Source, idxPath.Child("source"))...)
+		}
+	}
+	if spec.Hooks != nil {
+		allErrs = append(allErrs, validateHooks(spec.Hooks, fldPath.Child("hooks"))...)
+	}
+	if spec.Linux != nil {
+		allErrs = append(allErrs, validateLinux(spec.Linux, fldPath.Child("linux"))...)
+	}
+	for _, key := range slices.Sorted(maps.Keys(spec.Annotations)) {
+		keyPath := fldPath.Child("annotations").Key(key)
+		value := spec.Annotations[key]
+		switch {
+		case !slices.Contains(SupportedAnnotations, key):
+			allErrs = append(allErrs, field.NotSupported(keyPath, key, SupportedAnnotations))
+		case value == "":
+			allErrs = append(allErrs, field.Required(keyPath, ""))
+		case key == SeccompProfileAnnotation:
+			if filepath.IsAbs(value) || filepath.Clean(value) != value || value == ".." || strings.HasPrefix(value, "../") {
+				allErrs = append(allErrs, field.Invalid(keyPath, value, "must be a clean relative path below the seccomp profile root"))
+			}
+			if spec.Linux != nil && spec.Linux.Seccomp != nil {
+				allErrs = append(allErrs, field.Forbidden(keyPath, "cannot be combined with linux.seccomp"))
+			}
+		}
+	}
+
+	return allErrs
+}
+
+func validateProcess(process *Process, fldPath *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+	allErrs = append(allErrs, validateEnv(process.Env, fldPath.Child("env"))...)
+	allErrs = append(allErrs, validateRlimits(process.Rlimits, fldPath.Child("rlimits"))...)
+	if process.OOMScoreAdj != nil && (*process.OOMScoreAdj < -1000 || *process.OOMScoreAdj > 1000) {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("oomScoreAdj"), *process.OOMScoreAdj, "must be between -1000 and 1000"))
+	}
+	for _, name := range UnsupportedProcessFields(process) {
+		allErrs = append(allErrs, field.Forbidden(fldPath.Child(name), "cannot be applied to a container through NRI"))
+	}
+	return allErrs
+}
+
+// UnsupportedProcessFields returns the names of the fields set in process that
+// NRI has no container adjustment for.
+func UnsupportedProcessFields(process *Process) []string {
+	var fields []string
+	if process.Capabilities != nil {
+		fields = append(fields, "capabilities")
+	}
+	if process.NoNewPrivileges {
+		fields = append(fields, "noNewPrivileges")
+	}
+	if process.ApparmorProfile != "" {
+		fields = append(fields, "apparmorProfile")
+	}
+	if process.SelinuxLabel != "" {
+		fields = append(fields, "selinuxLabel")
+	}
+	return fields
+}
+
+func validateRlimits(rlimits []POSIXRlimit, fldPath *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+	seen := make(map[string]bool)
+	for i, r := range rlimits {
+		idxPath := fldPath.Index(i)
+		switch {
+		case !slices.Contains(rlimitTypes, r.Type):
+			allErrs = append(allErrs, field.NotSupported(idxPath.Child("type"), r.Type, rlimitTypes))
+		case seen[r.Type]:
+			allErrs = append(allErrs, field.Duplicate(idxPath.Child("type"), r.Type))
+		}
+		seen[r.Type] = true
+		if r.Soft > r.Hard {
+			allErrs = append(allErrs, field.Invalid(idxPath.Child("soft"), r.Soft, "must not be greater than hard"))
+		}
+	}
+	return allErrs
+}
+
+func validateEnv(env []string, fldPath *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+	for i, e := range env {
+		key, _, ok := strings.Cut(e, "=")
+		if !ok || key == "" {
+			allErrs = append(allErrs, field.Invalid(fldPath.Index(i), e, "must be of the form KEY=VALUE"))
+		}
+	}
+	return allErrs
+}
+
+// ValidateRemovals validates remove, rooted at fldPath, and rejects removing
+// what spec adds, since the outcome would depend on the order in which the
+// runtime applies them.
+func ValidateRemovals(remove *Removals, spec *RuntimeSpec, fldPath *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+	if remove == nil {
+		return allErrs
+	}
+
+	var added struct{ env, mounts, devices []string }
+	if spec != nil && spec.Process != nil {
+		for _, e := range spec.Process.Env {
+			name, _, _ := strings.Cut(e, "=")
+			added.env = append(added.env, name)
+		}
+	}
+	if spec != nil {
+		for _, m := range spec.Mounts {
+			added.mounts = append(added.mounts, m.Destination)
+		}
+	}
+	if spec != nil && spec.Linux != nil {
+		for _, d := range spec.Linux.Devices {
+			added.devices = append(added.devices, d.Path)
+		}
+	}
+
+	kinds := []struct {
+		name    string
+		entries []string
+		added   []string
+		check   func(string, *field.Path) field.ErrorList
+	}{
+		{"env", remove.Env, added.env, validateEnvName},
+		{"mounts", remove.Mounts, added.mounts, validateAbsolutePath},
+		{"devices", remove.Devices, added.devices, validateAbsolutePath},
+		{"annotations", remove.Annotations, nil, validateNonEmpty},
+	}
+	for _, kind := range kinds {
+		seen := make(map[string]bool)
+		for i, entry := range kind.entries {
+			idxPath := fldPath.Child(kind.name).Index(i)
+			allErrs = append(allErrs, kind.check(entry, idxPath)...)
+			if seen[entry] {
+				allErrs = append(allErrs, field.Duplicate(idxPath, entry))
+			}
+			seen[entry] = true
+			if slices.Contains(kind.added, entry) {
+				allErrs = append(allErrs, field.Invalid(idxPath, entry, fmt.Sprintf("is also set in spec %s", kind.name)))
+			}
+		}
+	}
+
+	return allErrs
+}
+
+func validateEnvName(name string, fldPath *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+	if name == "" || strings.Contains(name, "=") {
+		allErrs = append(allErrs, field.Invalid(fldPath, name, "must be an environment variable name"))
+	}
+	return allErrs
+}
+
+func validateNonEmpty(value string, fldPath *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+	if value == "" {
+		allErrs = append(allErrs, field.Required(fldPath, ""))
+	}
+	return allErrs
+}
+
+func validateAbsolutePath(path string, fldPath *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+	switch {
+	case path == "":
+		allErrs = append(allErrs, field.Required(fldPath, ""))
+	case !filepath.IsAbs(path):
+		allErrs = append(allErrs, field.Invalid(fldPath, path, "must be an absolute path"))
+	}
+	return allErrs
+}
+
+func validateHooks(hooks *Hooks, fldPath *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+	stages := []struct {
+		name  string
+		hooks []Hook
+	}{
+		{"prestart", hooks.Prestart},
+		{"createRuntime", hooks.CreateRuntime},
+		{"createContainer", hooks.CreateContainer},
+		{"startContainer", hooks.StartContainer},
+		{"poststart", hooks.Poststart},
+		{"poststop", hooks.Poststop},
+	}
+	for _, stage := range stages {
+		for i, h := range stage.hooks {
+			idxPath := fldPath.Child(stage.name).Index(i)
+			allErrs = append(allErrs, validateAbsolutePath(h.Path, idxPath.Child("path"))...)
+			if h.Timeout != nil && *h.Timeout <= 0 {
+				allErrs = append(allErrs, field.Invalid(idxPath.Child("timeout"), *h.Timeout, "must be greater than zero"))
+			}
+		}
+	}
+	return allErrs
+}
+
+func validateLinux(linux *Linux, fldPath *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+
+	for i, d := range linux.Devices {
+		idxPath := fldPath.Child("devices").Index(i)
+		allErrs = append(allErrs, validateAbsolutePath(d.Path, idxPath.Child("path"))...)
+		switch d.Type {
+		case "b", "c", "u", "p":
+		default:
+			allErrs = append(allErrs, field.NotSupported(idxPath.Child("type"), d.Type, []string{"b", "c", "u", "p"}))
+		}
+	}
+
+	allErrs = append(allErrs, validateNamespaces(linux.Namespaces, fldPath.Child("namespaces"))...)
+
+	if linux.Resources != nil {
+		allErrs = append(allErrs, validateResources(linux.Resources, fldPath.Child("resources"))...)
+	}
+
+	if linux.Seccomp != nil {
+		allErrs = append(allErrs, validateSeccomp(linux.Seccomp, fldPath.Child("seccomp"))...)
+	}
+
+	if linux.IntelRdt != nil {
+		closIDPath := fldPath.Child("intelRdt", "closID")
+		switch closID := linux.IntelRdt.ClosID; {
+		case closID == "":
+			allErrs = append(allErrs, field.Required(closIDPath, ""))
+		case closID == "." || closID == ".." || strings.ContainsAny(closID, "/\n"):
+			allErrs = append(allErrs, field.Invalid(closIDPath, closID, "must be a valid resctrl group name"))
+		}
+	}
+
+	for _, name := range slices.Sorted(maps.Keys(linux.Sysctl)) {
+		keyPath := fldPath.Child("sysctl").Key(name)
+		switch {
+		case !sysctlRegexp.MatchString(name):
+			allErrs = append(allErrs, field.Invalid(keyPath, name, "must be a sysctl name in dotted notation, e.g. net.core.somaxconn"))
+		case !isNamespacedSysctl(name):
+			allErrs = append(allErrs, field.Forbidden(keyPath, "only namespaced sysctls (kernel.shm*, kernel.msg*, kernel.sem, fs.mqueue.*, net.*) can be set for a container"))
+		}
+	}
+
+	return allErrs
+}
+
+// validateNamespaces allows joining a named network namespace below NetNSDir
+// and creating a new time namespace.
+func validateNamespaces(namespaces []LinuxNamespace, fldPath *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+
+	seen := make(map[string]bool)
+	for i, ns := range namespaces {
+		idxPath := fldPath.Index(i)
+		if seen[ns.Type] {
+			allErrs = append(allErrs, field.Duplicate(idxPath.Child("type"), ns.Type))
+		}
+		seen[ns.Type] = true
+
+		pathPath := idxPath.Child("path")
+		switch ns.Type {
+		case "network":
+			if name, ok := strings.CutPrefix(ns.Path, NetNSDir+"/"); !ok || !isFileName(name) {
+				allErrs = append(allErrs, field.Invalid(pathPath, ns.Path, fmt.Sprintf("must be a named network namespace in %s", NetNSDir)))
+			}
+		case "time":
+			if ns.Path != "" {
+				allErrs = append(allErrs, field.Forbidden(pathPath, "only new time namespaces are supported"))
+			}
+		default:
+			allErrs = append(allErrs, field.NotSupported(idxPath.Child("type"), ns.Type, []string{"network", "time"}))
+		}
+	}
+
+	return allErrs
+}
+
+// isFileName reports whether name is a single, non-special path element.
+func isFileName(name string) bool {
+	return name != "" && name != "." && name != ".." && !strings.Contains(name, "/")
+}
+
+func validateSeccomp(seccomp *LinuxSeccomp, fldPath *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+
+	if !slices.Contains(seccompActions, seccomp.DefaultAction) {
+		allErrs = append(allErrs, field.NotSupported(fldPath.Child("defaultAction"), seccomp.DefaultAction, seccompActions))
+	}
+	for i, sc := range seccomp.Syscalls {
+		idxPath := fldPath.Child("syscalls").Index(i)
+		if len(sc.Names) == 0 {
+			allErrs = append(allErrs, field.Required(idxPath.Child("names"), ""))
+		}
+		if !slices.Contains(seccompActions, sc.Action) {
+			allErrs = append(allErrs, field.NotSupported(idxPath.Child("action"), sc.Action, seccompActions))
+		}
+		for j, arg := range sc.Args {
+			if !slices.Contains(seccompOperators, arg.Op) {
+				allErrs = append(allErrs, field.NotSupported(idxPath.Child("args").Index(j).Child("op"), arg.Op, seccompOperators))
+			}
+		}
+	}
+	if seccomp.ListenerPath != "" {
+		allErrs = append(allErrs, validateAbsolutePath(seccomp.ListenerPath, fldPath.Child("listenerPath"))...)
+	}
+
+	return allErrs
+}
+
+func isNamespacedSysctl(name string) bool {
+	for _, prefix := range namespacedSysctlPrefixes {
+		if strings.HasPrefix(name, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+func validateResources(resources *LinuxResources, fldPath *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+
+	for i, hp := range resources.HugepageLimits {
+		if hp.Pagesize == "" {
+			allErrs = append(allErrs, field.Required(fldPath.Child("hugepageLimits").Index(i).Child("pageSize"), ""))
+		}
+	}
+
+	if resources.CPU != nil && resources.CPU.Idle != nil && *resources.CPU.Idle != 0 && *resources.CPU.Idle != 1 {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("cpu", "idle"), *resources.CPU.Idle, "must be 0 or 1"))
+	}
+
+	if resources.BlockIO != nil {
+		allErrs = append(allErrs, validateBlockIO(resources.BlockIO, fldPath.Child("blockIO"))...)
+	}
+
+	for _, name := range UnsupportedResourceFields(resources) {
+		allErrs = append(allErrs, field.Forbidden(fldPath.Child(name), "cannot be applied to a container through NRI"))
+	}
+
+	if resources.Pids != nil {
+		limitPath := fldPath.Child("pids", "limit")
+		if limit := resources.Pids.Limit; limit == 0 || limit < -1 {
+			allErrs = append(allErrs, field.Invalid(limitPath, limit, "must be greater than 0, or -1 for no limit"))
+		} else if value, ok := resources.Unified["pids.max"]; ok && value != pidsMax(limit) {
+			allErrs = append(allErrs, field.Invalid(limitPath, limit, fmt.Sprintf("conflicts with unified pids.max %q", value)))
+		}
+	}
+
+	unifiedPath := fldPath.Child("unified")
+	for _, key := range slices.Sorted(maps.Keys(resources.Unified)) {
+		value := resources.Unified[key]
+		keyPath := unifiedPath.Key(key)
+		if !unifiedKeyRegexp.MatchString(key) {
+			allErrs = append(allErrs, field.Invalid(keyPath, key, "must be of the form <controller>.<file>"))
+			continue
+		}
+		switch {
+		case unifiedLimitKeys[key]:
+			allErrs = append(allErrs, validateUnifiedLimit(value, keyPath)...)
+		case key == "io.max":
+			allErrs = append(allErrs, validateIOMax(value, keyPath)...)
+		case key == "io.weight":
+			allErrs = append(allErrs, validateIOWeight(value, keyPath)...)
+		}
+	}
+
+	// BlockIO and some CPU settings are applied as unified keys, so they must
+	// not contradict explicit values for those keys, and together they may
+	// still only set one device per io file.
+	if len(allErrs) == 0 {
+		unified, err := resources.EffectiveUnified()
+		if err != nil {
+			allErrs = append(allErrs, field.Invalid(fldPath, field.OmitValueType{}, err.Error()))
+		}
+		for _, key := range []string{"io.max", "io.weight"} {
+			if len(ioLines(unified[key])) > 1 {
+				allErrs = append(allErrs, field.Invalid(fldPath.Child("blockIO"), field.OmitValueType{}, fmt.Sprintf("together with unified %s %s", key, oneIODeviceMsg)))
+			}
+		}
+	}
+
+	return allErrs
+}
+
+// UnsupportedResourceFields returns the paths, relative to resources, of the
+// fields set in resources that NRI has no container adjustment for.
+func UnsupportedResourceFields(resources *LinuxResources) []string {
+	var fields []string
+	if resources.Memory != nil && resources.Memory.CheckBeforeUpdate != nil {
+		fields = append(fields, "memory.checkBeforeUpdate")
+	}
+	return fields
+}
+
+func validateBlockIO(blockIO *LinuxBlockIO, fldPath *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+
+	if blockIO.Weight != nil {
+		allErrs = append(allErrs, validateBlockIOWeight(*blockIO.Weight, fldPath.Child("weight"))...)
+	}
+	for i, wd := range blockIO.WeightDevice {
+		idxPath := fldPath.Child("weightDevice").Index(i)
+		allErrs = append(allErrs, validateBlockIODevice(wd.Major, wd.Minor, idxPath)...)
+		if wd.Weight != nil {
+			allErrs = append(allErrs, validateBlockIOWeight(*wd.Weight, idxPath.Child("weight"))...)
+		}
+	}
+
+	throttles := []struct {
+		name    string
+		devices []LinuxThrottleDevice
+	}{
+		{"throttleReadBpsDevice", blockIO.ThrottleReadBpsDevice},
+		{"throttleWriteBpsDevice", blockIO.ThrottleWriteBpsDevice},
+		{"throttleReadIOPSDevice", blockIO.ThrottleReadIOPSDevice},
+		{"throttleWriteIOPSDevice", blockIO.ThrottleWriteIOPSDevice},
+	}
+	if n := len(blockIO.WeightDevice); n > 1 || (n == 1 && blockIO.Weight != nil) {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("weightDevice"), field.OmitValueType{}, "together with weight "+oneIODeviceMsg))
+	}
+
+	throttled := make(map[string]bool)
+	for _, t := range throttles {
+		seen := make(map[string]bool)
+		for i, td := range t.devices {
+			idxPath := fldPath.Child(t.name).Index(i)
+			allErrs = append(allErrs, validateBlockIODevice(td.Major, td.Minor, idxPath)...)
+			device := fmt.Sprintf("%d:%d", td.Major, td.Minor)
+			if seen[device] {
+				allErrs = append(allErrs, field.Duplicate(idxPath, device))
+			}
+			seen[device] = true
+			throttled[device] = true
+		}
+	}
+	if len(throttled) > 1 {
+		allErrs = append(allErrs, field.Invalid(fldPath, slices.Sorted(maps.Keys(throttled)), "throttles "+oneIODeviceMsg))
+	}
+
+	return allErrs
+}
+
+func validateBlockIOWeight(weight uint16, fldPath *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+	if weight < 10 || weight > 1000 {
+		allErrs = append(allErrs, field.Invalid(fldPath, weight, "must be between 10 and 1000"))
+	}
+	return allErrs
+}
+
+func validateBlockIODevice(major, minor int64, fldPath *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+	if major < 0 {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("major"), major, "must be non-negative"))
+	}
+	if minor < 0 {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("minor"), minor, "must be non-negative"))
+	}
+	return allErrs
+}
+
+// pidsMax formats a pids limit as its pids.max value.
+func pidsMax(limit int64) string {
+	if limit < 0 {
+		return "max"
+	}
+	return strconv.FormatInt(limit, 10)
+}
+
+// validateUnifiedLimit checks for a non-negative integer or "max".
+func validateUnifiedLimit(value string, fldPath *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+	value = strings.TrimSpace(value)
+	if value == "max" {
+		return allErrs
+	}
+	if _, err := strconv.ParseUint(value, 10, 64); err != nil {
+		allErrs = append(allErrs, field.Invalid(fldPath, value, `must be a non-negative integer or "max"`))
+	}
+	return allErrs
+}
+
+// ioLines returns the non-empty lines of an io.max or io.weight value.
+func ioLines(value string) []string {
+	var lines []string
+	for _, line := range strings.Split(value, "\n") {
+		if strings.TrimSpace(line) != "" {
+			lines = append(lines, line)
+		}
+	}
+	return lines
+}
+
+// validateIOWeight checks that io.weight sets the weight of one device or the
+// default.
+func validateIOWeight(value string, fldPath *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+	if len(ioLines(value)) > 1 {
+		allErrs = append(allErrs, field.Invalid(fldPath, value, oneIODeviceMsg))
+	}
+	return allErrs
+}
+
+// validateIOMax checks the value of io.max, which is a single line of the form
+// "<major>:<minor> <key>=<value> ...".
+func validateIOMax(value string, fldPath *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+	lines := ioLines(value)
+	if len(lines) > 1 {
+		allErrs = append(allErrs, field.Invalid(fldPath, value, oneIODeviceMsg))
+	}
+	for _, line := range lines {
+		fields := strings.Fields(line)
+		if !blockDeviceRegexp.MatchString(fields[0]) {
+			allErrs = append(allErrs, field.Invalid(fldPath, line, "must start with a <major>:<minor> device number"))
+			continue
+		}
+		if len(fields) == 1 {
+			allErrs = append(allErrs, field.Invalid(fldPath, line, "must set at least one of rbps, wbps, riops or wiops"))
+			continue
+		}
+		for _, kv := range fields[1:] {
+			k, v, ok := strings.Cut(kv, "=")
+			if !ok || !ioMaxKeys[k] {
+				allErrs = append(allErrs, field.Invalid(fldPath, line, `limits must be one of rbps=, wbps=, riops= or wiops=`))
+				continue
+			}
+			if v == "max" {
+				continue
+			}
+			if _, err := strconv.ParseUint(v, 10, 64); err != nil {
+				allErrs = append(allErrs, field.Invalid(fldPath, line, `limit values must be a non-negative integer or "max"`))
+			}
+		}
+	}
+	return allErrs
+}
@@ -5,14 +5,802 @@
 package v1alpha1
 
 import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 )
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Hook) DeepCopyInto(out *Hook) {
+	*out = *in
+	if in.Args != nil {
+		in, out := &in.Args, &out.Args
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Env != nil {
+		in, out := &in.Env, &out.Env
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Timeout != nil {
+		in, out := &in.Timeout, &out.Timeout
+		*out = new(int)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Hook.
+func (in *Hook) DeepCopy() *Hook {
+	if in == nil {
+		return nil
+	}
+	out := new(Hook)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Hooks) DeepCopyInto(out *Hooks) {
+	*out = *in
+	if in.Prestart != nil {
+		in, out := &in.Prestart, &out.Prestart
+		*out = make([]Hook, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.CreateRuntime != nil {
+		in, out := &in.CreateRuntime, &out.CreateRuntime
+		*out = make([]Hook, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.CreateContainer != nil {
+		in, out := &in.CreateContainer, &out.CreateContainer
+		*out = make([]Hook, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.StartContainer != nil {
+		in, out := &in.StartContainer, &out.StartContainer
+		*out = make([]Hook, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Poststart != nil {
+		in, out := &in.Poststart, &out.Poststart
+		*out = make([]Hook, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Poststop != nil {
+		in, out := &in.Poststop, &out.Poststop
+		*out = make([]Hook, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Hooks.
+func (in *Hooks) DeepCopy() *Hooks {
+	if in == nil {
+		return nil
+	}
+	out := new(Hooks)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *IOLimit) DeepCopyInto(out *IOLimit) {
+	*out = *in
+	if in.Rbps != nil {
+		in, out := &in.Rbps, &out.Rbps
+		*out = new(uint64)
+		**out = **in
+	}
+	if in.Wbps != nil {
+		in, out := &in.Wbps, &out.Wbps
+		*out = new(uint64)
+		**out = **in
+	}
+	if in.Riops != nil {
+		in, out := &in.Riops, &out.Riops
+		*out = new(uint64)
+		**out = **in
+	}
+	if in.Wiops != nil {
+		in, out := &in.Wiops, &out.Wiops
+		*out = new(uint64)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new IOLimit.
+func (in *IOLimit) DeepCopy() *IOLimit {
+	if in == nil {
+		return nil
+	}
+	out := new(IOLimit)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Linux) DeepCopyInto(out *Linux) {
+	*out = *in
+	if in.Resources != nil {
+		in, out := &in.Resources, &out.Resources
+		*out = new(LinuxResources)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Devices != nil {
+		in, out := &in.Devices, &out.Devices
+		*out = make([]LinuxDevice, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Namespaces != nil {
+		in, out := &in.Namespaces, &out.Namespaces
+		*out = make([]LinuxNamespace, len(*in))
+		copy(*out, *in)
+	}
+	if in.Sysctl != nil {
+		in, out := &in.Sysctl, &out.Sysctl
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.IntelRdt != nil {
+		in, out := &in.IntelRdt, &out.IntelRdt
+		*out = new(LinuxIntelRdt)
+		**out = **in
+	}
+	if in.Seccomp != nil {
+		in, out := &in.Seccomp, &out.Seccomp
+		*out = new(LinuxSeccomp)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Linux.
+func (in *Linux) DeepCopy() *Linux {
+	if in == nil {
+		return nil
+	}
+	out := new(Linux)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *LinuxBlockIO) DeepCopyInto(out *LinuxBlockIO) {
+	*out = *in
+	if in.Weight != nil {
+		in, out := &in.Weight, &out.Weight
+		*out = new(uint16)
+		**out = **in
+	}
+	if in.WeightDevice != nil {
+		in, out := &in.WeightDevice, &out.WeightDevice
+		*out = make([]LinuxWeightDevice, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.ThrottleReadBpsDevice != nil {
+		in, out := &in.ThrottleReadBpsDevice, &out.ThrottleReadBpsDevice
+		*out = make([]LinuxThrottleDevice, len(*in))
+		copy(*out, *in)
+	}
+	if in.ThrottleWriteBpsDevice != nil {
+		in, out := &in.ThrottleWriteBpsDevice, &out.ThrottleWriteBpsDevice
+		*out = make([]LinuxThrottleDevice, len(*in))
+		copy(*out, *in)
+	}
+	if in.ThrottleReadIOPSDevice != nil {
+		in, out := &in.ThrottleReadIOPSDevice, &out.ThrottleReadIOPSDevice
+		*out = make([]LinuxThrottleDevice, len(*in))
+		copy(*out, *in)
+	}
+	if in.ThrottleWriteIOPSDevice != nil {
+		in, out := &in.ThrottleWriteIOPSDevice, &out.ThrottleWriteIOPSDevice
+		*out = make([]LinuxThrottleDevice, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new LinuxBlockIO.
+func (in *LinuxBlockIO) DeepCopy() *LinuxBlockIO {
+	if in == nil {
+		return nil
+	}
+	out := new(LinuxBlockIO)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *LinuxCPU) DeepCopyInto(out *LinuxCPU) {
+	*out = *in
+	if in.Shares != nil {
+		in, out := &in.Shares, &out.Shares
+		*out = new(uint64)
+		**out = **in
+	}
+	if in.Quota != nil {
+		in, out := &in.Quota, &out.Quota
+		*out = new(int64)
+		**out = **in
+	}
+	if in.Burst != nil {
+		in, out := &in.Burst, &out.Burst
+		*out = new(uint64)
+		**out = **in
+	}
+	if in.Period != nil {
+		in, out := &in.Period, &out.Period
+		*out = new(uint64)
+		**out = **in
+	}
+	if in.RealtimeRuntime != nil {
+		in, out := &in.RealtimeRuntime, &out.RealtimeRuntime
+		*out = new(int64)
+		**out = **in
+	}
+	if in.RealtimePeriod != nil {
+		in, out := &in.RealtimePeriod, &out.RealtimePeriod
+		*out = new(uint64)
+		**out = **in
+	}
+	if in.Idle != nil {
+		in, out := &in.Idle, &out.Idle
+		*out = new(int64)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new LinuxCPU.
+func (in *LinuxCPU) DeepCopy() *LinuxCPU {
+	if in == nil {
+		return nil
+	}
+	out := new(LinuxCPU)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *LinuxCapabilities) DeepCopyInto(out *LinuxCapabilities) {
+	*out = *in
+	if in.Bounding != nil {
+		in, out := &in.Bounding, &out.Bounding
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Effective != nil {
+		in, out := &in.Effective, &out.Effective
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Inheritable != nil {
+		in, out := &in.Inheritable, &out.Inheritable
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Permitted != nil {
+		in, out := &in.Permitted, &out.Permitted
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Ambient != nil {
+		in, out := &in.Ambient, &out.Ambient
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new LinuxCapabilities.
+func (in *LinuxCapabilities) DeepCopy() *LinuxCapabilities {
+	if in == nil {
+		return nil
+	}
+	out := new(LinuxCapabilities)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *LinuxDevice) DeepCopyInto(out *LinuxDevice) {
+	*out = *in
+	if in.FileMode != nil {
+		in, out := &in.FileMode, &out.FileMode
+		*out = new(uint32)
+		**out = **in
+	}
+	if in.UID != nil {
+		in, out := &in.UID, &out.UID
+		*out = new(uint32)
+		**out = **in
+	}
+	if in.GID != nil {
+		in, out := &in.GID, &out.GID
+		*out = new(uint32)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new LinuxDevice.
+func (in *LinuxDevice) DeepCopy() *LinuxDevice {
+	if in == nil {
+		return nil
+	}
+	out := new(LinuxDevice)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *LinuxHugepageLimit) DeepCopyInto(out *LinuxHugepageLimit) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new LinuxHugepageLimit.
+func (in *LinuxHugepageLimit) DeepCopy() *LinuxHugepageLimit {
+	if in == nil {
+		return nil
+	}
+	out := new(LinuxHugepageLimit)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *LinuxIntelRdt) DeepCopyInto(out *LinuxIntelRdt) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new LinuxIntelRdt.
+func (in *LinuxIntelRdt) DeepCopy() *LinuxIntelRdt {
+	if in == nil {
+		return nil
+	}
+	out := new(LinuxIntelRdt)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *LinuxMemory) DeepCopyInto(out *LinuxMemory) {
+	*out = *in
+	if in.Limit != nil {
+		in, out := &in.Limit, &out.Limit
+		*out = new(int64)
+		**out = **in
+	}
+	if in.Reservation != nil {
+		in, out := &in.Reservation, &out.Reservation
+		*out = new(int64)
+		**out = **in
+	}
+	if in.Swap != nil {
+		in, out := &in.Swap, &out.Swap
+		*out = new(int64)
+		**out = **in
+	}
+	if in.Kernel != nil {
+		in, out := &in.Kernel, &out.Kernel
+		*out = new(int64)
+		**out = **in
+	}
+	if in.KernelTCP != nil {
+		in, out := &in.KernelTCP, &out.KernelTCP
+		*out = new(int64)
+		**out = **in
+	}
+	if in.Swappiness != nil {
+		in, out := &in.Swappiness, &out.Swappiness
+		*out = new(uint64)
+		**out = **in
+	}
+	if in.DisableOOMKiller != nil {
+		in, out := &in.DisableOOMKiller, &out.DisableOOMKiller
+		*out = new(bool)
+		**out = **in
+	}
+	if in.UseHierarchy != nil {
+		in, out := &in.UseHierarchy, &out.UseHierarchy
+		*out = new(bool)
+		**out = **in
+	}
+	if in.CheckBeforeUpdate != nil {
+		in, out := &in.CheckBeforeUpdate, &out.CheckBeforeUpdate
+		*out = new(bool)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new LinuxMemory.
+func (in *LinuxMemory) DeepCopy() *LinuxMemory {
+	if in == nil {
+		return nil
+	}
+	out := new(LinuxMemory)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *LinuxNamespace) DeepCopyInto(out *LinuxNamespace) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new LinuxNamespace.
+func (in *LinuxNamespace) DeepCopy() *LinuxNamespace {
+	if in == nil {
+		return nil
+	}
+	out := new(LinuxNamespace)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *LinuxPids) DeepCopyInto(out *LinuxPids) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new LinuxPids.
+func (in *LinuxPids) DeepCopy() *LinuxPids {
+	if in == nil {
+		return nil
+	}
+	out := new(LinuxPids)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *LinuxResources) DeepCopyInto(out *LinuxResources) {
+	*out = *in
+	if in.Memory != nil {
+		in, out := &in.Memory, &out.Memory
+		*out = new(LinuxMemory)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.CPU != nil {
+		in, out := &in.CPU, &out.CPU
+		*out = new(LinuxCPU)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.BlockIO != nil {
+		in, out := &in.BlockIO, &out.BlockIO
+		*out = new(LinuxBlockIO)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Pids != nil {
+		in, out := &in.Pids, &out.Pids
+		*out = new(LinuxPids)
+		**out = **in
+	}
+	if in.HugepageLimits != nil {
+		in, out := &in.HugepageLimits, &out.HugepageLimits
+		*out = make([]LinuxHugepageLimit, len(*in))
+		copy(*out, *in)
+	}
+	if in.Unified != nil {
+		in, out := &in.Unified, &out.Unified
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new LinuxResources.
+func (in *LinuxResources) DeepCopy() *LinuxResources {
+	if in == nil {
+		return nil
+	}
+	out := new(LinuxResources)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *LinuxSeccomp) DeepCopyInto(out *LinuxSeccomp) {
+	*out = *in
+	if in.DefaultErrnoRet != nil {
+		in, out := &in.DefaultErrnoRet, &out.DefaultErrnoRet
+		*out = new(uint)
+		**out = **in
+	}
+	if in.Architectures != nil {
+		in, out := &in.Architectures, &out.Architectures
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Flags != nil {
+		in, out := &in.Flags, &out.Flags
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Syscalls != nil {
+		in, out := &in.Syscalls, &out.Syscalls
+		*out = make([]LinuxSyscall, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new LinuxSeccomp.
+func (in *LinuxSeccomp) DeepCopy() *LinuxSeccomp {
+	if in == nil {
+		return nil
+	}
+	out := new(LinuxSeccomp)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *LinuxSeccompArg) DeepCopyInto(out *LinuxSeccompArg) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new LinuxSeccompArg.
+func (in *LinuxSeccompArg) DeepCopy() *LinuxSeccompArg {
+	if in == nil {
+		return nil
+	}
+	out := new(LinuxSeccompArg)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *LinuxSyscall) DeepCopyInto(out *LinuxSyscall) {
+	*out = *in
+	if in.Names != nil {
+		in, out := &in.Names, &out.Names
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.ErrnoRet != nil {
+		in, out := &in.ErrnoRet, &out.ErrnoRet
+		*out = new(uint)
+		**out = **in
+	}
+	if in.Args != nil {
+		in, out := &in.Args, &out.Args
+		*out = make([]LinuxSeccompArg, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new LinuxSyscall.
+func (in *LinuxSyscall) DeepCopy() *LinuxSyscall {
+	if in == nil {
+		return nil
+	}
+	out := new(LinuxSyscall)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *LinuxThrottleDevice) DeepCopyInto(out *LinuxThrottleDevice) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new LinuxThrottleDevice.
+func (in *LinuxThrottleDevice) DeepCopy() *LinuxThrottleDevice {
+	if in == nil {
+		return nil
+	}
+	out := new(LinuxThrottleDevice)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *LinuxWeightDevice) DeepCopyInto(out *LinuxWeightDevice) {
+	*out = *in
+	if in.Weight != nil {
+		in, out := &in.Weight, &out.Weight
+		*out = new(uint16)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new LinuxWeightDevice.
+func (in *LinuxWeightDevice) DeepCopy() *LinuxWeightDevice {
+	if in == nil {
+		return nil
+	}
+	out := new(LinuxWeightDevice)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Mount) DeepCopyInto(out *Mount) {
+	*out = *in
+	if in.Options != nil {
+		in, out := &in.Options, &out.Options
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Mount.
+func (in *Mount) DeepCopy() *Mount {
+	if in == nil {
+		return nil
+	}
+	out := new(Mount)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *POSIXRlimit) DeepCopyInto(out *POSIXRlimit) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new POSIXRlimit.
+func (in *POSIXRlimit) DeepCopy() *POSIXRlimit {
+	if in == nil {
+		return nil
+	}
+	out := new(POSIXRlimit)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Process) DeepCopyInto(out *Process) {
+	*out = *in
+	if in.Env != nil {
+		in, out := &in.Env, &out.Env
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Rlimits != nil {
+		in, out := &in.Rlimits, &out.Rlimits
+		*out = make([]POSIXRlimit, len(*in))
+		copy(*out, *in)
+	}
+	if in.OOMScoreAdj != nil {
+		in, out := &in.OOMScoreAdj, &out.OOMScoreAdj
+		*out = new(int)
+		**out = **in
+	}
+	if in.Capabilities != nil {
+		in, out := &in.Capabilities, &out.Capabilities
+		*out = new(LinuxCapabilities)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Process.
+func (in *Process) DeepCopy() *Process {
+	if in == nil {
+		return nil
+	}
+	out := new(Process)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Removals) DeepCopyInto(out *Removals) {
+	*out = *in
+	if in.Env != nil {
+		in, out := &in.Env, &out.Env
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Mounts != nil {
+		in, out := &in.Mounts, &out.Mounts
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Devices != nil {
+		in, out := &in.Devices, &out.Devices
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Annotations != nil {
+		in, out := &in.Annotations, &out.Annotations
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Removals.
+func (in *Removals) DeepCopy() *Removals {
+	if in == nil {
+		return nil
+	}
+	out := new(Removals)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RuntimeSpec) DeepCopyInto(out *RuntimeSpec) {
+	*out = *in
+	if in.Process != nil {
+		in, out := &in.Process, &out.Process
+		*out = new(Process)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Mounts != nil {
+		in, out := &in.Mounts, &out.Mounts
+		*out = make([]Mount, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Hooks != nil {
+		in, out := &in.Hooks, &out.Hooks
+		*out = new(Hooks)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Linux != nil {
+		in, out := &in.Linux, &out.Linux
+		*out = new(Linux)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Annotations != nil {
+		in, out := &in.Annotations, &out.Annotations
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RuntimeSpec.
+func (in *RuntimeSpec) DeepCopy() *RuntimeSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(RuntimeSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *RuntimeSpecEditConfig) DeepCopyInto(out *RuntimeSpecEditConfig) {
 	*out = *in
 	out.TypeMeta = in.TypeMeta
-	in.Spec.DeepCopyInto(&out.Spec)
+	if in.IOLimits != nil {
+		in, out := &in.IOLimits, &out.IOLimits
+		*out = make([]IOLimit, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Remove != nil {
+		in, out := &in.Remove, &out.Remove
+		*out = new(Removals)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Spec != nil {
+		in, out := &in.Spec, &out.Spec
+		*out = new(RuntimeSpec)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RuntimeSpecEditConfig.
@@ -32,3 +820,169 @@ func (in *RuntimeSpecEditConfig) DeepCopyObject() runtime.Object {
 	}
 	return nil
 }
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RuntimeSpecPolicy) DeepCopyInto(out *RuntimeSpecPolicy) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RuntimeSpecPolicy.
+func (in *RuntimeSpecPolicy) DeepCopy() *RuntimeSpecPolicy {
+	if in == nil {
+		return nil
+	}
+	out := new(RuntimeSpecPolicy)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *RuntimeSpecPolicy) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RuntimeSpecPolicyList) DeepCopyInto(out *RuntimeSpecPolicyList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]RuntimeSpecPolicy, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RuntimeSpecPolicyList.
+func (in *RuntimeSpecPolicyList) DeepCopy() *RuntimeSpecPolicyList {
+	if in == nil {
+		return nil
+	}
+	out := new(RuntimeSpecPolicyList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *RuntimeSpecPolicyList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RuntimeSpecPolicyRules) DeepCopyInto(out *RuntimeSpecPolicyRules) {
+	*out = *in
+	if in.AllowedFields != nil {
+		in, out := &in.AllowedFields, &out.AllowedFields
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.DeniedFields != nil {
+		in, out := &in.DeniedFields, &out.DeniedFields
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.AllowedHookPaths != nil {
+		in, out := &in.AllowedHookPaths, &out.AllowedHookPaths
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.AllowedMountSources != nil {
+		in, out := &in.AllowedMountSources, &out.AllowedMountSources
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.AllowedSysctls != nil {
+		in, out := &in.AllowedSysctls, &out.AllowedSysctls
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.AllowedSeccompProfiles != nil {
+		in, out := &in.AllowedSeccompProfiles, &out.AllowedSeccompProfiles
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.AllowedNamespaces != nil {
+		in, out := &in.AllowedNamespaces, &out.AllowedNamespaces
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.AllowedMergeStrategies != nil {
+		in, out := &in.AllowedMergeStrategies, &out.AllowedMergeStrategies
+		*out = make([]MergeStrategy, len(*in))
+		copy(*out, *in)
+	}
+	if in.Unified != nil {
+		in, out := &in.Unified, &out.Unified
+		*out = make([]UnifiedRule, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RuntimeSpecPolicyRules.
+func (in *RuntimeSpecPolicyRules) DeepCopy() *RuntimeSpecPolicyRules {
+	if in == nil {
+		return nil
+	}
+	out := new(RuntimeSpecPolicyRules)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RuntimeSpecPolicySpec) DeepCopyInto(out *RuntimeSpecPolicySpec) {
+	*out = *in
+	if in.NamespaceSelector != nil {
+		in, out := &in.NamespaceSelector, &out.NamespaceSelector
+		*out = new(metav1.LabelSelector)
+		(*in).DeepCopyInto(*out)
+	}
+	in.RuntimeSpecPolicyRules.DeepCopyInto(&out.RuntimeSpecPolicyRules)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RuntimeSpecPolicySpec.
+func (in *RuntimeSpecPolicySpec) DeepCopy() *RuntimeSpecPolicySpec {
+	if in == nil {
+		return nil
+	}
+	out := new(RuntimeSpecPolicySpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *UnifiedRule) DeepCopyInto(out *UnifiedRule) {
+	*out = *in
+	if in.Min != nil {
+		in, out := &in.Min, &out.Min
+		*out = new(uint64)
+		**out = **in
+	}
+	if in.Max != nil {
+		in, out := &in.Max, &out.Max
+		*out = new(uint64)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new UnifiedRule.
+func (in *UnifiedRule) DeepCopy() *UnifiedRule {
+	if in == nil {
+		return nil
+	}
+	out := new(UnifiedRule)
+	in.DeepCopyInto(out)
+	return out
+}
@@ -5,19 +5,26 @@ import (
 	"fmt"
 	"maps"
 
+	corev1 "k8s.io/api/core/v1"
 	resourceapi "k8s.io/api/resource/v1beta1"
 	"k8s.io/apimachinery/pkg/types"
 	coreclientset "k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/scheme"
+	typedcorev1 "k8s.io/client-go/kubernetes/typed/core/v1"
+	"k8s.io/client-go/tools/record"
 	"k8s.io/dynamic-resource-allocation/kubeletplugin"
 	"k8s.io/dynamic-resource-allocation/resourceslice"
 	"k8s.io/klog/v2"
+
+	"runtime-spec-dra-driver/pkg/policy"
 )
 
 type driver struct {
-	client      coreclientset.Interface
-	helper      *kubeletplugin.Helper
-	state       *DeviceState
-	healthcheck *healthcheck
+	client           coreclientset.Interface
+	helper           *kubeletplugin.Helper
+	state            *DeviceState
+	healthcheck      *healthcheck
+	eventBroadcaster record.EventBroadcaster
 }
 
 func NewDriver(ctx context.Context, config *Config) (*driver, error) {
@@ -25,7 +32,26 @@ func NewDriver(ctx context.Context, config *Config) (*driver, error) {
 		client: config.coreclient,
 	}
 
-	state, err := NewDeviceState(config)
+	var clusterPolicies *policy.ClusterPolicies
+	var recorder record.EventRecorder
+	if config.flags.namespacePolicies {
+		var err error
+		clusterPolicies, err = policy.NewClusterPolicies(ctx, config.coreclient, config.dynamicclient)
+		if err != nil {
+			return nil, fmt.Errorf("start RuntimeSpecPolicy informer: %w", err)
+		}
+
+		driver.eventBroadcaster = record.NewBroadcaster(record.WithContext(ctx))
+		driver.eventBroadcaster.StartRecordingToSink(&typedcorev1.EventSinkImpl{
+			Interface: config.coreclient.CoreV1().Events(""),
+		})
+		recorder = driver.eventBroadcaster.NewRecorder(scheme.Scheme, corev1.EventSource{
+			Component: DriverName,
+			Host:      config.flags.nodeName,
+		})
+	}
+
+	state, err := NewDeviceState(config, clusterPolicies, recorder)
 	if err != nil {
 		return nil, err
 	}
@@ -74,6 +100,9 @@ func (d *driver) Shutdown(logger klog.Logger) error {
 		d.healthcheck.Stop(logger)
 	}
 	d.helper.Stop()
+	if d.eventBroadcaster != nil {
+		d.eventBroadcaster.Shutdown()
+	}
 	return nil
 }
 
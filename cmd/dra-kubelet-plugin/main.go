@@ -11,6 +11,7 @@ import (
 
 	"github.com/urfave/cli/v2"
 
+	"k8s.io/client-go/dynamic"
 	coreclientset "k8s.io/client-go/kubernetes"
 	"k8s.io/dynamic-resource-allocation/kubeletplugin"
 	"k8s.io/klog/v2"
@@ -32,11 +33,14 @@ type Flags struct {
 	kubeletRegistrarDirectoryPath string
 	kubeletPluginsDirectoryPath   string
 	healthcheckPort               int
+	policyFile                    string
+	namespacePolicies             bool
 }
 
 type Config struct {
-	flags      *Flags
-	coreclient coreclientset.Interface
+	flags         *Flags
+	coreclient    coreclientset.Interface
+	dynamicclient dynamic.Interface
 }
 
 func (c Config) DriverPluginPath() string {
@@ -90,6 +94,18 @@ func newApp() *cli.App {
 			Destination: &flags.healthcheckPort,
 			EnvVars:     []string{"HEALTHCHECK_PORT"},
 		},
+		&cli.StringFlag{
+			Name:        "policy-file",
+			Usage:       "Absolute path to a node policy file restricting which runtime spec fields claims may set. When empty, all supported fields are allowed.",
+			Destination: &flags.policyFile,
+			EnvVars:     []string{"POLICY_FILE"},
+		},
+		&cli.BoolFlag{
+			Name:        "namespace-policies",
+			Usage:       "Require claims to be admitted by a cluster-scoped RuntimeSpecPolicy selecting their namespace, in addition to the node policy.",
+			Destination: &flags.namespacePolicies,
+			EnvVars:     []string{"NAMESPACE_POLICIES"},
+		},
 	}
 	cliFlags = append(cliFlags, flags.kubeClientConfig.Flags()...)
 	cliFlags = append(cliFlags, flags.loggingConfig.Flags()...)
@@ -114,8 +130,9 @@ func newApp() *cli.App {
 			}
 
 			config := &Config{
-				flags:      flags,
-				coreclient: clientSets.Core,
+				flags:         flags,
+				coreclient:    clientSets.Core,
+				dynamicclient: clientSets.Dynamic,
 			}
 
 			return RunPlugin(ctx, config)
@@ -1,16 +1,24 @@
 package main
 
 import (
+	"errors"
 	"fmt"
+	"os"
+	"path/filepath"
 	"slices"
 	"sync"
 
+	corev1 "k8s.io/api/core/v1"
 	resourceapi "k8s.io/api/resource/v1beta1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/klog/v2"
 	drapbv1 "k8s.io/kubelet/pkg/apis/dra/v1beta1"
 	"k8s.io/kubernetes/pkg/kubelet/checkpointmanager"
 
 	configapi "runtime-spec-dra-driver/api/v1alpha1"
+	"runtime-spec-dra-driver/pkg/handoff"
+	"runtime-spec-dra-driver/pkg/policy"
 
 	cdiapi "tags.cncf.io/container-device-interface/pkg/cdi"
 	cdispec "tags.cncf.io/container-device-interface/specs-go"
@@ -29,6 +37,9 @@ type OpaqueDeviceConfig struct {
 type PreparedDevice struct {
 	drapbv1.Device
 	ContainerEdits *cdiapi.ContainerEdits
+	// SpecFile is the config file handed off to the NRI plugin for this
+	// device. It is removed when the claim is unprepared.
+	SpecFile string
 }
 
 func (pds PreparedDevices) GetDevices() []*drapbv1.Device {
@@ -44,9 +55,13 @@ type DeviceState struct {
 	cdi               *CDIHandler
 	allocatable       AllocatableDevices
 	checkpointManager checkpointmanager.CheckpointManager
+	specDir           string
+	policy            *policy.Policy
+	clusterPolicies   *policy.ClusterPolicies
+	recorder          record.EventRecorder
 }
 
-func NewDeviceState(config *Config) (*DeviceState, error) {
+func NewDeviceState(config *Config, clusterPolicies *policy.ClusterPolicies, recorder record.EventRecorder) (*DeviceState, error) {
 	allocatable, err := enumerateAllPossibleDevices()
 	if err != nil {
 		return nil, fmt.Errorf("error enumerating all possible devices: %v", err)
@@ -62,10 +77,27 @@ func NewDeviceState(config *Config) (*DeviceState, error) {
 		return nil, fmt.Errorf("unable to create checkpoint manager: %v", err)
 	}
 
+	specDir := filepath.Join(config.DriverPluginPath(), handoff.DirName)
+	if err := os.MkdirAll(specDir, 0700); err != nil {
+		return nil, fmt.Errorf("unable to create spec directory: %v", err)
+	}
+
+	var nodePolicy *policy.Policy
+	if config.flags.policyFile != "" {
+		nodePolicy, err = policy.Load(config.flags.policyFile)
+		if err != nil {
+			return nil, fmt.Errorf("unable to load node policy: %v", err)
+		}
+	}
+
 	state := &DeviceState{
 		cdi:               cdi,
 		allocatable:       allocatable,
 		checkpointManager: checkpointManager,
+		specDir:           specDir,
+		policy:            nodePolicy,
+		clusterPolicies:   clusterPolicies,
+		recorder:          recorder,
 	}
 
 	checkpoints, err := state.checkpointManager.ListCheckpoints()
@@ -100,16 +132,21 @@ func (s *DeviceState) Prepare(claim *resourceapi.ResourceClaim) ([]*drapbv1.Devi
 	preparedClaims := checkpoint.V1.PreparedClaims
 
 	if preparedClaims[claimUID] != nil {
+		// A shared claim is prepared again for every pod that starts
+		// consuming it, so let the new pods use its config files.
+		if err := s.updateOwner(claim, preparedClaims[claimUID]); err != nil {
+			return nil, err
+		}
 		return preparedClaims[claimUID].GetDevices(), nil
 	}
 
 	preparedDevices, err := s.prepareDevices(claim)
 	if err != nil {
-		return nil, fmt.Errorf("prepare failed: %v", err)
+		return nil, errors.Join(fmt.Errorf("prepare failed: %v", err), handoff.RemoveClaim(s.specDir, claimUID))
 	}
 
 	if err = s.cdi.CreateClaimSpecFile(claimUID, preparedDevices); err != nil {
-		return nil, fmt.Errorf("unable to create CDI spec file for claim: %v", err)
+		return nil, errors.Join(fmt.Errorf("unable to create CDI spec file for claim: %v", err), handoff.RemoveClaim(s.specDir, claimUID))
 	}
 
 	preparedClaims[claimUID] = preparedDevices
@@ -200,8 +237,14 @@ func (s *DeviceState) prepareDevices(claim *resourceapi.ResourceClaim) (Prepared
 			return nil, fmt.Errorf("error normalizing config: %w", err)
 		}
 
+		// Validate the config to ensure it only requests changes that can
+		// actually be applied to the container.
+		if err := config.Validate(); err != nil {
+			return nil, fmt.Errorf("error validating config: %w", err)
+		}
+
 		// Apply the config to the list of results associated with it.
-		containerEdits, err := s.applyConfig(config, results)
+		containerEdits, err := s.applyConfig(claim, config, results)
 		if err != nil {
 			return nil, fmt.Errorf("error applying config: %w", err)
 		}
@@ -229,6 +272,7 @@ func (s *DeviceState) prepareDevices(claim *resourceapi.ResourceClaim) (Prepared
 					CDIDeviceIDs: cdiDevices,
 				},
 				ContainerEdits: perDeviceCDIContainerEdits[result.Device],
+				SpecFile:       handoff.Path(s.specDir, handoff.Ref{ClaimUID: string(claim.UID), Device: result.Device}),
 			}
 			preparedDevices = append(preparedDevices, device)
 		}
@@ -237,21 +281,91 @@ func (s *DeviceState) prepareDevices(claim *resourceapi.ResourceClaim) (Prepared
 	return preparedDevices, nil
 }
 
-func (s *DeviceState) unprepareDevices(claimUID string, devices PreparedDevices) error {
+// updateOwner records the pods claim is currently reserved for in the config
+// files of its prepared devices.
+func (s *DeviceState) updateOwner(claim *resourceapi.ResourceClaim, devices PreparedDevices) error {
+	owner := claimOwner(claim)
+	for _, device := range devices {
+		if device.SpecFile == "" {
+			continue
+		}
+		ref := handoff.Ref{ClaimUID: string(claim.UID), Device: device.DeviceName}
+		f, err := handoff.Read(s.specDir, ref)
+		if err != nil {
+			return fmt.Errorf("unable to read spec file for device %s: %w", device.DeviceName, err)
+		}
+		if f.Owner.Namespace == owner.Namespace && slices.Equal(f.Owner.PodUIDs, owner.PodUIDs) {
+			continue
+		}
+		f.Owner = owner
+		if _, err := handoff.Write(s.specDir, ref, f); err != nil {
+			return fmt.Errorf("error writing spec file for device %s: %w", device.DeviceName, err)
+		}
+	}
 	return nil
 }
 
+// claimOwner returns the owner recorded in the config files of claim: its
+// namespace and the pods it is reserved for.
+func claimOwner(claim *resourceapi.ResourceClaim) handoff.Owner {
+	owner := handoff.Owner{Namespace: claim.Namespace}
+	for _, consumer := range claim.Status.ReservedFor {
+		if consumer.APIGroup == "" && consumer.Resource == "pods" {
+			owner.PodUIDs = append(owner.PodUIDs, string(consumer.UID))
+		}
+	}
+	return owner
+}
+
+func (s *DeviceState) unprepareDevices(claimUID string, devices PreparedDevices) error {
+	for _, device := range devices {
+		if device.SpecFile == "" {
+			continue
+		}
+		if err := os.Remove(device.SpecFile); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("unable to remove spec file for device %s: %w", device.DeviceName, err)
+		}
+	}
+	return handoff.RemoveClaim(s.specDir, claimUID)
+}
+
 // applyConfig applies a configuration to a set of device allocation results.
 //
-// The configuration is passed to containers via the OCI_RUNTIME_SPEC environment
-// variable. The NRI plugin reads this environment variable during the CreateContainer
-// phase and applies the spec adjustments (including unified cgroup parameters).
-func (s *DeviceState) applyConfig(config *configapi.RuntimeSpecEditConfig, results []*resourceapi.DeviceRequestAllocationResult) (PerDeviceCDIContainerEdits, error) {
+// The configuration is written to a per-device file below the driver plugin
+// directory, and only a reference to it is passed to containers via a
+// per-device OCI_RUNTIME_SPEC_REF_<claim>_<device> environment variable. The
+// NRI plugin resolves the references during the CreateContainer phase, merges
+// the specs and applies the resulting adjustments (including unified cgroup
+// parameters). Each file records the claim's namespace and the pods it is
+// reserved for, and the NRI plugin applies it to containers of those pods only.
+// Configs denied by the node policy, or not admitted by any RuntimeSpecPolicy
+// for the claim's namespace, are rejected before any edits are emitted.
+func (s *DeviceState) applyConfig(claim *resourceapi.ResourceClaim, config *configapi.RuntimeSpecEditConfig, results []*resourceapi.DeviceRequestAllocationResult) (PerDeviceCDIContainerEdits, error) {
 	perDeviceEdits := make(PerDeviceCDIContainerEdits)
 
+	if err := s.policy.CheckConfig(config); err != nil {
+		return nil, fmt.Errorf("config denied by node policy: %w", err)
+	}
+
+	if s.clusterPolicies != nil {
+		admittedBy, err := s.clusterPolicies.Check(claim.Namespace, config)
+		if err != nil {
+			s.recorder.Eventf(claim, corev1.EventTypeWarning, "RuntimeSpecPolicyDenied", "Config denied: %v", err)
+			return nil, fmt.Errorf("config denied by RuntimeSpecPolicy: %w", err)
+		}
+		klog.Infof("Config for claim %s/%s admitted by RuntimeSpecPolicy %q", claim.Namespace, claim.Name, admittedBy)
+		s.recorder.Eventf(claim, corev1.EventTypeNormal, "RuntimeSpecPolicyAdmitted", "Config admitted by RuntimeSpecPolicy %q", admittedBy)
+	}
+
+	f := &handoff.File{Owner: claimOwner(claim), Config: config}
 	for _, result := range results {
+		ref := handoff.Ref{ClaimUID: string(claim.UID), Device: result.Device}
+		if _, err := handoff.Write(s.specDir, ref, f); err != nil {
+			return nil, fmt.Errorf("error writing spec file for device %s: %w", result.Device, err)
+		}
+
 		env := []string{
-			fmt.Sprintf("OCI_RUNTIME_SPEC=%s", string(config.Spec.Raw)),
+			fmt.Sprintf("%s=%s", ref.EnvKey(), ref),
 		}
 
 		perDeviceEdits[result.Device] = &cdiapi.ContainerEdits{
@@ -316,7 +430,7 @@ func GetOpaqueDeviceConfigs(
 
 		decodedConfig, err := runtime.Decode(decoder, config.DeviceConfiguration.Opaque.Parameters.Raw)
 		if err != nil {
-			return nil, fmt.Errorf("error decoding config parameters: %w", err)
+			return nil, fmt.Errorf("error decoding config parameters: %w", configapi.DescribeDecodeError(err))
 		}
 
 		resultConfig := &OpaqueDeviceConfig{
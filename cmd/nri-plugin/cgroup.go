@@ -0,0 +1,233 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+
+	"golang.org/x/sys/unix"
+
+	configapi "runtime-spec-dra-driver/api/v1alpha1"
+)
+
+// CgroupRoot is where the host's cgroup hierarchy is mounted.
+const CgroupRoot = "/sys/fs/cgroup"
+
+// isCgroup2UnifiedMode reports whether root is a cgroup v2 mount, i.e. the
+// host runs the unified hierarchy only. Hybrid hosts mount a tmpfs at root and
+// manage resources through cgroup v1, so they are reported as v1 as well.
+func isCgroup2UnifiedMode(root string) (bool, error) {
+	var st unix.Statfs_t
+	if err := unix.Statfs(root, &st); err != nil {
+		return false, fmt.Errorf("unable to stat %s: %w", root, err)
+	}
+	return st.Type == unix.CGROUP2_SUPER_MAGIC, nil
+}
+
+// convertToCgroupV1 returns a copy of spec for a cgroup v1 host. Unified
+// parameters, including blockIO translated to io.weight and io.max, are moved
+// to the equivalent memory, cpu, pids and hugepage settings. A unified key
+// without a v1 equivalent, or one contradicting an explicit setting, is an
+// error rather than being dropped. The spec returned with an error holds the
+// settings that could be converted.
+func convertToCgroupV1(spec *configapi.RuntimeSpec) (*configapi.RuntimeSpec, error) {
+	if spec.Linux == nil || spec.Linux.Resources == nil {
+		return spec, nil
+	}
+
+	spec = spec.DeepCopy()
+	res := spec.Linux.Resources
+	unified, err := res.EffectiveUnified()
+	res.BlockIO = nil
+	res.Unified = nil
+	if err != nil {
+		return spec, err
+	}
+	if len(unified) == 0 {
+		return spec, nil
+	}
+
+	c := &v1Converter{res: res}
+	var unsupported []string
+	// memory.swap.max depends on the memory limit, so it is converted after
+	// memory.max, which sorts first.
+	for _, key := range slices.Sorted(maps.Keys(unified)) {
+		value := strings.TrimSpace(unified[key])
+		switch {
+		case key == "memory.max":
+			c.setMemory(key, &c.memory().Limit, value)
+		case key == "memory.low":
+			c.setMemory(key, &c.memory().Reservation, value)
+		case key == "memory.swap.max":
+			c.setSwap(key, value)
+		case key == "cpu.weight":
+			c.setCPUWeight(key, value)
+		case key == "cpu.max":
+			c.setCPUMax(key, value)
+		case key == "cpuset.cpus":
+			c.setString(key, &c.cpu().Cpus, value)
+		case key == "cpuset.mems":
+			c.setString(key, &c.cpu().Mems, value)
+		case key == "pids.max":
+			c.setPids(key, value)
+		case strings.HasPrefix(key, "hugetlb.") && strings.HasSuffix(key, ".max"):
+			c.setHugepageLimit(key, value)
+		default:
+			unsupported = append(unsupported, key)
+		}
+	}
+
+	if len(unsupported) > 0 {
+		c.errs = append(c.errs, fmt.Errorf("unified keys %v have no cgroup v1 equivalent", unsupported))
+	}
+	if len(c.errs) > 0 {
+		return spec, fmt.Errorf("unable to apply spec on a cgroup v1 host: %w", errors.Join(c.errs...))
+	}
+	return spec, nil
+}
+
+// v1Converter collects the cgroup v1 settings converted from unified keys.
+type v1Converter struct {
+	res  *configapi.LinuxResources
+	errs []error
+}
+
+func (c *v1Converter) memory() *configapi.LinuxMemory {
+	if c.res.Memory == nil {
+		c.res.Memory = &configapi.LinuxMemory{}
+	}
+	return c.res.Memory
+}
+
+func (c *v1Converter) cpu() *configapi.LinuxCPU {
+	if c.res.CPU == nil {
+		c.res.CPU = &configapi.LinuxCPU{}
+	}
+	return c.res.CPU
+}
+
+func (c *v1Converter) invalid(key, value string) {
+	c.errs = append(c.errs, fmt.Errorf("invalid value %q for unified %s", value, key))
+}
+
+func (c *v1Converter) conflict(key, value string, existing any) {
+	c.errs = append(c.errs, fmt.Errorf("unified %s %q conflicts with the explicitly set value %v", key, value, existing))
+}
+
+// setMemory sets a memory byte limit, mapping "max" to unlimited (-1).
+func (c *v1Converter) setMemory(key string, dst **int64, value string) {
+	limit, ok := parseUnifiedLimit(value)
+	if !ok {
+		c.invalid(key, value)
+		return
+	}
+	setPtr(c, key, dst, limit, value)
+}
+
+// setSwap sets the v1 memory+swap limit from the v2 swap-only limit, which
+// requires the memory limit to be known.
+func (c *v1Converter) setSwap(key, value string) {
+	swap, ok := parseUnifiedLimit(value)
+	if !ok {
+		c.invalid(key, value)
+		return
+	}
+	mem := c.memory()
+	switch {
+	case mem.Limit == nil:
+		c.errs = append(c.errs, fmt.Errorf("unified %s requires a memory limit on cgroup v1", key))
+		return
+	case swap >= 0 && *mem.Limit > 0:
+		swap += *mem.Limit
+	default:
+		swap = -1
+	}
+	setPtr(c, key, &mem.Swap, swap, value)
+}
+
+// setCPUWeight sets cpu shares from a cgroup v2 weight (1-10000), inverting
+// the conversion runc applies to shares on cgroup v2.
+func (c *v1Converter) setCPUWeight(key, value string) {
+	weight, err := strconv.ParseUint(value, 10, 64)
+	if err != nil || weight < 1 || weight > 10000 {
+		c.invalid(key, value)
+		return
+	}
+	setPtr(c, key, &c.cpu().Shares, 2+(weight-1)*262142/9999, value)
+}
+
+// setCPUMax sets the cpu quota and, if given, period from "<quota> [<period>]".
+func (c *v1Converter) setCPUMax(key, value string) {
+	fields := strings.Fields(value)
+	if len(fields) == 0 || len(fields) > 2 {
+		c.invalid(key, value)
+		return
+	}
+	quota, ok := parseUnifiedLimit(fields[0])
+	if !ok {
+		c.invalid(key, value)
+		return
+	}
+	setPtr(c, key, &c.cpu().Quota, quota, value)
+	if len(fields) == 2 {
+		period, err := strconv.ParseUint(fields[1], 10, 64)
+		if err != nil {
+			c.invalid(key, value)
+			return
+		}
+		setPtr(c, key, &c.cpu().Period, period, value)
+	}
+}
+
+func (c *v1Converter) setPids(key, value string) {
+	limit, ok := parseUnifiedLimit(value)
+	if !ok {
+		c.invalid(key, value)
+		return
+	}
+	if c.res.Pids == nil {
+		c.res.Pids = &configapi.LinuxPids{Limit: limit}
+	} else if c.res.Pids.Limit != limit {
+		c.conflict(key, value, c.res.Pids.Limit)
+	}
+}
+
+// setHugepageLimit sets the limit for the page size in hugetlb.<size>.max.
+func (c *v1Converter) setHugepageLimit(key, value string) {
+	pageSize := strings.TrimSuffix(strings.TrimPrefix(key, "hugetlb."), ".max")
+	limit, err := strconv.ParseUint(value, 10, 64)
+	if err != nil {
+		c.invalid(key, value)
+		return
+	}
+	for _, hp := range c.res.HugepageLimits {
+		if hp.Pagesize == pageSize {
+			if hp.Limit != limit {
+				c.conflict(key, value, hp.Limit)
+			}
+			return
+		}
+	}
+	c.res.HugepageLimits = append(c.res.HugepageLimits, configapi.LinuxHugepageLimit{Pagesize: pageSize, Limit: limit})
+}
+
+func (c *v1Converter) setString(key string, dst *string, value string) {
+	switch {
+	case *dst == "":
+		*dst = value
+	case *dst != value:
+		c.conflict(key, value, *dst)
+	}
+}
+
+func setPtr[T comparable](c *v1Converter, key string, dst **T, v T, value string) {
+	switch {
+	case *dst == nil:
+		*dst = &v
+	case **dst != v:
+		c.conflict(key, value, **dst)
+	}
+}
@@ -0,0 +1,368 @@
+package main
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/containerd/nri/pkg/api"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"k8s.io/klog/v2"
+	"k8s.io/utils/cpuset"
+
+	configapi "runtime-spec-dra-driver/api/v1alpha1"
+)
+
+// applyMergeStrategy reconciles the resource settings of spec with the
+// resources the kubelet already set on the container, so that a claim cannot
+// loosen limits the scheduler accounted for unless its config allows it.
+//
+// With MergeStrategyOverride spec is returned as is. With
+// MergeStrategyOnlyTighten a copy of spec without the loosening settings is
+// returned. With MergeStrategyFailOnConflict any loosening setting is an error.
+func applyMergeStrategy(strategy configapi.MergeStrategy, source string, spec *configapi.RuntimeSpec, current *api.LinuxResources) (*configapi.RuntimeSpec, error) {
+	if strategy == configapi.MergeStrategyOverride || current == nil || spec.Linux == nil || spec.Linux.Resources == nil {
+		return spec, nil
+	}
+
+	spec = spec.DeepCopy()
+	loosened := tightenResources(spec.Linux.Resources, current, field.NewPath("spec", "linux", "resources"))
+	if len(loosened) == 0 {
+		return spec, nil
+	}
+
+	if strategy == configapi.MergeStrategyFailOnConflict {
+		return nil, fmt.Errorf("spec from %s conflicts with the container's resources: %w", source, loosened.ToAggregate())
+	}
+	for _, err := range loosened {
+		klog.Infof("Skipping setting from %s: %v", source, err)
+	}
+	return spec, nil
+}
+
+// tightenResources removes every setting from res that would loosen a limit
+// in current and returns an error for each of them.
+func tightenResources(res *configapi.LinuxResources, current *api.LinuxResources, fldPath *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+	loosens := func(fldPath *field.Path, requested, limit any) {
+		allErrs = append(allErrs, field.Forbidden(fldPath, fmt.Sprintf("%v would loosen the container's limit of %v", requested, limit)))
+	}
+
+	if mem, cur := res.Memory, current.GetMemory(); mem != nil && cur != nil {
+		memPath := fldPath.Child("memory")
+		if mem.Limit != nil && cur.GetLimit() != nil && looserLimit(*mem.Limit, cur.GetLimit().GetValue()) {
+			loosens(memPath.Child("limit"), *mem.Limit, cur.GetLimit().GetValue())
+			mem.Limit = nil
+		}
+		if mem.Swap != nil && cur.GetSwap() != nil && looserLimit(*mem.Swap, cur.GetSwap().GetValue()) {
+			loosens(memPath.Child("swap"), *mem.Swap, cur.GetSwap().GetValue())
+			mem.Swap = nil
+		}
+	}
+
+	if cpu, cur := res.CPU, current.GetCpu(); cpu != nil && cur != nil {
+		cpuPath := fldPath.Child("cpu")
+		if cpu.Shares != nil && cur.GetShares() != nil && cur.GetShares().GetValue() > 0 && *cpu.Shares > cur.GetShares().GetValue() {
+			loosens(cpuPath.Child("shares"), *cpu.Shares, cur.GetShares().GetValue())
+			cpu.Shares = nil
+		}
+		if (cpu.Quota != nil || cpu.Period != nil) && cur.GetQuota() != nil && cur.GetPeriod() != nil {
+			curQuota, curPeriod := cur.GetQuota().GetValue(), cur.GetPeriod().GetValue()
+			quota, period := curQuota, curPeriod
+			if cpu.Quota != nil {
+				quota = *cpu.Quota
+			}
+			if cpu.Period != nil {
+				period = *cpu.Period
+			}
+			if looserQuota(quota, period, curQuota, curPeriod) {
+				loosens(cpuPath.Child("quota"), fmt.Sprintf("%d/%d", quota, period), fmt.Sprintf("%d/%d", curQuota, curPeriod))
+				cpu.Quota, cpu.Period = nil, nil
+			}
+		}
+		if cpu.Cpus != "" && !isSubset(cpu.Cpus, cur.GetCpus()) {
+			loosens(cpuPath.Child("cpus"), cpu.Cpus, cur.GetCpus())
+			cpu.Cpus = ""
+		}
+		if cpu.Mems != "" && !isSubset(cpu.Mems, cur.GetMems()) {
+			loosens(cpuPath.Child("mems"), cpu.Mems, cur.GetMems())
+			cpu.Mems = ""
+		}
+	}
+
+	// CPU burst and idle are applied as unified keys, so compare them as such.
+	if cpu := res.CPU; cpu != nil {
+		cpuPath := fldPath.Child("cpu")
+		if cpu.Burst != nil {
+			burst := strconv.FormatUint(*cpu.Burst, 10)
+			if limit, loosening, err := unifiedLoosens("cpu.max.burst", burst, current); err != nil || loosening {
+				loosens(cpuPath.Child("burst"), *cpu.Burst, limit)
+				cpu.Burst = nil
+			}
+		}
+		if cpu.Idle != nil {
+			idle := strconv.FormatInt(*cpu.Idle, 10)
+			if limit, loosening, err := unifiedLoosens("cpu.idle", idle, current); err != nil || loosening {
+				loosens(cpuPath.Child("idle"), *cpu.Idle, limit)
+				cpu.Idle = nil
+			}
+		}
+	}
+
+	if pids, cur := res.Pids, current.GetPids(); pids != nil && cur != nil && looserLimit(pids.Limit, cur.GetLimit()) {
+		loosens(fldPath.Child("pids", "limit"), pids.Limit, cur.GetLimit())
+		res.Pids = nil
+	}
+
+	if len(res.HugepageLimits) > 0 {
+		hugepagePath := fldPath.Child("hugepageLimits")
+		res.HugepageLimits = slices.DeleteFunc(res.HugepageLimits, func(hp configapi.LinuxHugepageLimit) bool {
+			for _, c := range current.GetHugepageLimits() {
+				if c.GetPageSize() == hp.Pagesize && hp.Limit > c.GetLimit() {
+					loosens(hugepagePath.Key(hp.Pagesize), hp.Limit, c.GetLimit())
+					return true
+				}
+			}
+			return false
+		})
+	}
+
+	unifiedPath := fldPath.Child("unified")
+	for _, key := range slices.Sorted(maps.Keys(res.Unified)) {
+		limit, loosening, err := unifiedLoosens(key, res.Unified[key], current)
+		switch {
+		case err != nil:
+			allErrs = append(allErrs, field.Forbidden(unifiedPath.Key(key), err.Error()))
+			delete(res.Unified, key)
+		case loosening:
+			loosens(unifiedPath.Key(key), res.Unified[key], limit)
+			delete(res.Unified, key)
+		}
+	}
+
+	return allErrs
+}
+
+// looserLimit reports whether requested is a weaker limit than current. A
+// limit of zero or less means unlimited.
+func looserLimit(requested, current int64) bool {
+	if current <= 0 {
+		return false
+	}
+	return requested <= 0 || requested > current
+}
+
+// looserQuota reports whether the CPU bandwidth quota/period is larger than
+// curQuota/curPeriod. A quota of zero or less means unlimited.
+func looserQuota(quota int64, period uint64, curQuota int64, curPeriod uint64) bool {
+	if curQuota <= 0 || curPeriod == 0 {
+		return false
+	}
+	if quota <= 0 || period == 0 {
+		return true
+	}
+	return float64(quota)/float64(period) > float64(curQuota)/float64(curPeriod)
+}
+
+// isSubset reports whether the cpuset list requested is contained in
+// current. An empty current list means all CPUs or memory nodes.
+func isSubset(requested, current string) bool {
+	if current == "" {
+		return true
+	}
+	req, err := cpuset.Parse(requested)
+	if err != nil {
+		return false
+	}
+	cur, err := cpuset.Parse(current)
+	if err != nil {
+		return false
+	}
+	return req.IsSubsetOf(cur)
+}
+
+// limitedControllers are the cgroup v2 controllers the kubelet sets limits
+// in. Unified keys of other controllers cannot loosen those limits.
+var limitedControllers = []string{"cpu", "cpuset", "memory", "pids", "hugetlb"}
+
+// unifiedLoosens reports whether setting the unified key to value would loosen
+// the container's current setting, which it returns for error messages. Keys
+// of limitedControllers that cannot be compared with the container's settings
+// are an error, since they might loosen a limit the scheduler accounted for.
+func unifiedLoosens(key, value string, current *api.LinuxResources) (string, bool, error) {
+	controller, _, _ := strings.Cut(key, ".")
+	if !slices.Contains(limitedControllers, controller) {
+		return "", false, nil
+	}
+	value = strings.TrimSpace(value)
+	curValue, hasCur := current.GetUnified()[key]
+
+	switch key {
+	case "cpu.max":
+		quota, period, ok := parseCPUMax(value)
+		if !ok {
+			return "", false, fmt.Errorf("value %q cannot be compared with the container's CPU quota", value)
+		}
+		curQuota, curPeriod, ok := currentCPUMax(current)
+		if !ok {
+			return "", false, nil
+		}
+		return fmt.Sprintf("%d/%d", curQuota, curPeriod), looserQuota(quota, period, curQuota, curPeriod), nil
+	case "cpuset.cpus", "cpuset.mems":
+		cur := current.GetCpu().GetCpus()
+		if key == "cpuset.mems" {
+			cur = current.GetCpu().GetMems()
+		}
+		if hasCur {
+			cur = strings.TrimSpace(curValue)
+		}
+		return cur, !isSubset(value, cur), nil
+	case "memory.oom.group":
+		// Killing the whole container on OOM does not loosen any limit.
+		return "", false, nil
+	}
+
+	requested, ok := parseUnifiedLimit(value)
+	if !ok {
+		return "", false, fmt.Errorf("value %q cannot be compared with the container's limits", value)
+	}
+	limit, ok := currentUnifiedValue(current, key)
+	if !ok {
+		return "", false, fmt.Errorf("unified key %s cannot be compared with the container's limits", key)
+	}
+	if hasCur {
+		if limit, ok = parseUnifiedLimit(curValue); !ok {
+			return "", false, fmt.Errorf("the container's value %q cannot be compared", curValue)
+		}
+	}
+
+	if key == "cpu.idle" {
+		// SCHED_IDLE lowers the container's priority, so leaving it loosens.
+		return strconv.FormatInt(limit, 10), requested < limit, nil
+	}
+	return formatUnifiedLimit(limit), looserUnifiedLimit(requested, limit), nil
+}
+
+// currentUnifiedValue returns the value the container has for the unified
+// key, derived from its cgroup v1 style settings, or the kernel default. It
+// only reports false for keys that cannot be compared. Limits use -1 for
+// "max". Values set through the container's unified parameters take
+// precedence and are handled by the caller.
+func currentUnifiedValue(current *api.LinuxResources, key string) (int64, bool) {
+	switch key {
+	case "memory.max":
+		if limit := current.GetMemory().GetLimit(); limit != nil && limit.GetValue() > 0 {
+			return limit.GetValue(), true
+		}
+		return -1, true
+	case "memory.swap.max":
+		// The v1 swap limit covers memory and swap, as runc converts it.
+		mem := current.GetMemory()
+		if swap, limit := mem.GetSwap(), mem.GetLimit(); swap != nil && limit != nil && swap.GetValue() > 0 && limit.GetValue() > 0 {
+			return max(swap.GetValue()-limit.GetValue(), 0), true
+		}
+		return -1, true
+	case "memory.high", "memory.swap.high", "memory.zswap.max":
+		return -1, true
+	case "memory.min", "memory.low":
+		// Protection is not a limit: raising it takes memory from others.
+		return 0, true
+	case "pids.max":
+		if limit := current.GetPids(); limit != nil && limit.GetLimit() > 0 {
+			return limit.GetLimit(), true
+		}
+		return -1, true
+	case "cpu.weight":
+		if shares := current.GetCpu().GetShares(); shares != nil && shares.GetValue() > 0 {
+			return int64(configapi.CPUSharesToWeight(shares.GetValue())), true
+		}
+		return -1, true
+	case "cpu.max.burst":
+		// Bursting lets the container exceed its quota.
+		if quota, _, ok := currentCPUMax(current); ok && quota > 0 {
+			return 0, true
+		}
+		return -1, true
+	case "cpu.idle":
+		return 0, true
+	}
+	if pageSize, ok := strings.CutPrefix(key, "hugetlb."); ok {
+		if pageSize, ok = strings.CutSuffix(pageSize, ".max"); ok && !strings.Contains(pageSize, ".") {
+			for _, hp := range current.GetHugepageLimits() {
+				if hp.GetPageSize() == pageSize {
+					return int64(hp.GetLimit()), true
+				}
+			}
+			return -1, true
+		}
+	}
+	return 0, false
+}
+
+// currentCPUMax returns the container's CPU quota and period, from its unified
+// cpu.max or its cgroup v1 style settings. It reports false if neither is set.
+func currentCPUMax(current *api.LinuxResources) (int64, uint64, bool) {
+	if value, ok := current.GetUnified()["cpu.max"]; ok {
+		return parseCPUMax(strings.TrimSpace(value))
+	}
+	cpu := current.GetCpu()
+	if cpu.GetQuota() == nil || cpu.GetPeriod() == nil {
+		return 0, 0, false
+	}
+	return cpu.GetQuota().GetValue(), cpu.GetPeriod().GetValue(), true
+}
+
+// parseCPUMax parses a cpu.max value of the form "<quota> [<period>]", mapping
+// "max" to unlimited (-1). The period defaults to the kernel's 100ms.
+func parseCPUMax(value string) (int64, uint64, bool) {
+	fields := strings.Fields(value)
+	if len(fields) == 0 || len(fields) > 2 {
+		return 0, 0, false
+	}
+	quota, ok := parseUnifiedLimit(fields[0])
+	if !ok {
+		return 0, 0, false
+	}
+	period := uint64(100000)
+	if len(fields) == 2 {
+		p, err := strconv.ParseUint(fields[1], 10, 64)
+		if err != nil {
+			return 0, 0, false
+		}
+		period = p
+	}
+	return quota, period, true
+}
+
+// looserUnifiedLimit reports whether requested is a weaker unified limit than
+// current, with -1 meaning "max". Unlike looserLimit, zero is a limit.
+func looserUnifiedLimit(requested, current int64) bool {
+	if current < 0 {
+		return false
+	}
+	return requested < 0 || requested > current
+}
+
+// formatUnifiedLimit formats a limit as its unified value.
+func formatUnifiedLimit(limit int64) string {
+	if limit < 0 {
+		return "max"
+	}
+	return strconv.FormatInt(limit, 10)
+}
+
+// parseUnifiedLimit parses a single-value unified limit, mapping "max" to
+// unlimited (-1).
+func parseUnifiedLimit(value string) (int64, bool) {
+	value = strings.TrimSpace(value)
+	if value == "max" {
+		return -1, true
+	}
+	n, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return 0, false
+	}
+	return n, true
+}
@@ -0,0 +1,252 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/containerd/nri/pkg/api"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/klog/v2"
+
+	configapi "runtime-spec-dra-driver/api/v1alpha1"
+)
+
+// watchCgroups compares the cgroup files of the tracked containers with the
+// unified parameters last applied to them every interval until ctx is done.
+func (p *Plugin) watchCgroups(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			p.checkDrift()
+		}
+	}
+}
+
+// checkDrift records the tracked containers whose cgroup files no longer hold
+// the unified parameters applied to them, e.g. because another agent or the
+// workload through a delegated cgroup changed them, and reapplies their live
+// settings if reapplyDrift is set. Drift is reported as a pod event when it is
+// first detected. Only cgroup v2 hosts are checked, where the
+// unified parameters map to cgroup files one to one.
+func (p *Plugin) checkDrift() {
+	if !p.cgroup2 {
+		klog.V(3).Info("Skipping cgroup drift check on cgroup v1 host")
+		return
+	}
+
+	var updates []*api.ContainerUpdate
+	drifted := 0
+	for _, c := range p.tracked() {
+		pod, container := c.pod, c.container
+		if c.spec.Linux == nil {
+			continue
+		}
+		unified, err := c.spec.Linux.Resources.EffectiveUnified()
+		if err != nil || len(unified) == 0 {
+			continue
+		}
+		dir, err := cgroupDir(p.cgroupRoot, container.GetLinux().GetCgroupsPath())
+		if err != nil {
+			klog.V(2).Infof("Skipping cgroup drift check for container %s in pod %s/%s: %v",
+				container.GetName(), pod.GetNamespace(), pod.GetName(), err)
+			continue
+		}
+
+		diverged := unifiedDrift(dir, unified)
+		firstDetection := p.setDrifted(c, len(diverged) > 0)
+		if len(diverged) == 0 {
+			continue
+		}
+		drifted++
+		var changes []string
+		for _, key := range slices.Sorted(maps.Keys(diverged)) {
+			cgroupDriftTotal.WithLabelValues(key).Inc()
+			klog.Warningf("Cgroup drift for container %s in pod %s/%s: %s is %q, expected %q",
+				container.GetName(), pod.GetNamespace(), pod.GetName(), key, diverged[key], unified[key])
+			changes = append(changes, fmt.Sprintf("%s is %q, expected %q", key, diverged[key], unified[key]))
+		}
+		if firstDetection {
+			p.podEvent(pod, corev1.EventTypeWarning, "CgroupDrift",
+				"Cgroup of container %s no longer matches its runtime spec: %s", container.GetName(), strings.Join(changes, ", "))
+		}
+
+		if p.reapplyDrift {
+			update, err := liveSettingsOf(c.spec).containerUpdate(container.GetId())
+			if err != nil {
+				klog.Errorf("Failed to create update for container %s in pod %s/%s: %v",
+					container.GetName(), pod.GetNamespace(), pod.GetName(), err)
+				continue
+			}
+			updates = append(updates, update)
+		}
+	}
+	cgroupDriftContainers.Set(float64(drifted))
+
+	if len(updates) == 0 {
+		return
+	}
+	failed, err := p.stub.UpdateContainers(updates)
+	if err != nil {
+		klog.Errorf("Failed to reapply settings to drifted containers: %v", err)
+		return
+	}
+	for _, update := range failed {
+		klog.Errorf("Failed to reapply settings to container %s", update.GetContainerId())
+	}
+	cgroupDriftReappliedTotal.Add(float64(len(updates) - len(failed)))
+}
+
+// setDrifted records whether the cgroup files of c have drifted and reports
+// whether they drifted since the last check.
+func (p *Plugin) setDrifted(c *trackedContainer, drifted bool) bool {
+	p.index.Lock()
+	defer p.index.Unlock()
+	first := drifted && !c.drifted
+	c.drifted = drifted
+	return first
+}
+
+// cgroupDir returns the directory of a container's cgroup below root. The
+// cgroups path is either a path relative to the hierarchy, as used by the
+// cgroupfs driver, or "<slice>:<prefix>:<name>", as used by the systemd driver.
+func cgroupDir(root, cgroupsPath string) (string, error) {
+	if cgroupsPath == "" {
+		return "", fmt.Errorf("no cgroups path")
+	}
+	if parts := strings.Split(cgroupsPath, ":"); len(parts) == 3 {
+		slice, err := expandSlice(parts[0])
+		if err != nil {
+			return "", err
+		}
+		unit := parts[2]
+		if !strings.HasSuffix(unit, ".slice") {
+			unit = parts[1] + "-" + unit + ".scope"
+		}
+		return filepath.Join(root, slice, unit), nil
+	}
+	return filepath.Join(root, filepath.Clean("/"+cgroupsPath)), nil
+}
+
+// expandSlice returns the path of a systemd slice in the cgroup hierarchy,
+// e.g. "a-b.slice" is "a.slice/a-b.slice".
+func expandSlice(slice string) (string, error) {
+	name, ok := strings.CutSuffix(slice, ".slice")
+	if !ok || strings.Contains(name, "/") {
+		return "", fmt.Errorf("invalid slice name %q", slice)
+	}
+	if name == "-" || name == "" {
+		return "", nil
+	}
+
+	var path, prefix string
+	for _, component := range strings.Split(name, "-") {
+		if component == "" {
+			return "", fmt.Errorf("invalid slice name %q", slice)
+		}
+		prefix += component
+		path = filepath.Join(path, prefix+".slice")
+		prefix += "-"
+	}
+	return path, nil
+}
+
+// unifiedDrift returns the current value of each unified parameter in dir
+// that does not match the value in unified. Files that cannot be read, e.g.
+// because the container has exited, are skipped.
+func unifiedDrift(dir string, unified map[string]string) map[string]string {
+	diverged := make(map[string]string)
+	for key, want := range unified {
+		got, err := readCgroupFile(dir, key)
+		if err != nil {
+			klog.V(3).Infof("Unable to read %s: %v", key, err)
+			continue
+		}
+		if !cgroupValueMatches(key, want, got) {
+			diverged[key] = got
+		}
+	}
+	return diverged
+}
+
+func readCgroupFile(dir, key string) (string, error) {
+	if strings.ContainsRune(key, '/') {
+		return "", fmt.Errorf("invalid unified key %q", key)
+	}
+	f, err := os.Open(filepath.Join(dir, key))
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	data, err := io.ReadAll(f)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(data)), nil
+}
+
+// cgroupValueMatches reports whether the content of a cgroup file, got,
+// reflects the unified value want. io.max and io.weight list every configured
+// device, so only the devices and limits in want are compared. Memory sizes
+// are rounded down to the page size by the kernel, and cpu.max may omit the
+// period.
+func cgroupValueMatches(key, want, got string) bool {
+	switch {
+	case key == "io.max":
+		current := deviceLines(got)
+		for device, limits := range deviceLines(want) {
+			for _, limit := range limits {
+				if !slices.Contains(current[device], limit) && !(current[device] == nil && strings.HasSuffix(limit, "=max")) {
+					return false
+				}
+			}
+		}
+		return true
+	case key == "io.weight":
+		current := deviceLines(got)
+		for device, weight := range deviceLines(want) {
+			if len(weight) == 0 {
+				// A bare weight is the default.
+				device, weight = configapi.IOWeightDefault, []string{device}
+			}
+			if !slices.Equal(current[device], weight) {
+				return false
+			}
+		}
+		return true
+	case key == "cpu.max":
+		wantFields, gotFields := strings.Fields(want), strings.Fields(got)
+		return len(wantFields) <= len(gotFields) && slices.Equal(wantFields, gotFields[:len(wantFields)])
+	case strings.HasPrefix(key, "memory."):
+		wantBytes, err1 := strconv.ParseInt(strings.TrimSpace(want), 10, 64)
+		gotBytes, err2 := strconv.ParseInt(got, 10, 64)
+		if err1 == nil && err2 == nil {
+			pageSize := int64(os.Getpagesize())
+			return wantBytes/pageSize*pageSize == gotBytes
+		}
+	}
+	return strings.Join(strings.Fields(want), " ") == strings.Join(strings.Fields(got), " ")
+}
+
+// deviceLines parses io.max and io.weight lines into their fields keyed by the
+// first one, the device.
+func deviceLines(value string) map[string][]string {
+	lines := make(map[string][]string)
+	for _, line := range strings.Split(value, "\n") {
+		if fields := strings.Fields(line); len(fields) > 0 {
+			lines[fields[0]] = fields[1:]
+		}
+	}
+	return lines
+}
@@ -0,0 +1,201 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	"github.com/containerd/nri/pkg/api"
+	"github.com/containerd/nri/pkg/stub"
+	"k8s.io/client-go/tools/record"
+
+	configapi "runtime-spec-dra-driver/api/v1alpha1"
+)
+
+// fakeStub records the updates the plugin sends to the runtime.
+type fakeStub struct {
+	stub.Stub
+	updates []*api.ContainerUpdate
+}
+
+func (s *fakeStub) UpdateContainers(updates []*api.ContainerUpdate) ([]*api.ContainerUpdate, error) {
+	s.updates = append(s.updates, updates...)
+	return nil, nil
+}
+
+func TestCgroupDir(t *testing.T) {
+	tests := []struct {
+		name        string
+		cgroupsPath string
+		want        string
+		wantErr     bool
+	}{
+		{
+			name:        "cgroupfs",
+			cgroupsPath: "/kubepods/burstable/pod1234/abc",
+			want:        "/sys/fs/cgroup/kubepods/burstable/pod1234/abc",
+		},
+		{
+			name:        "cgroupfs escaping the root",
+			cgroupsPath: "../../etc",
+			want:        "/sys/fs/cgroup/etc",
+		},
+		{
+			name:        "systemd slice expansion",
+			cgroupsPath: "kubepods-burstable-pod1234.slice:cri-containerd:abc",
+			want:        "/sys/fs/cgroup/kubepods.slice/kubepods-burstable.slice/kubepods-burstable-pod1234.slice/cri-containerd-abc.scope",
+		},
+		{
+			name:        "systemd root slice",
+			cgroupsPath: "-.slice:cri-containerd:abc",
+			want:        "/sys/fs/cgroup/cri-containerd-abc.scope",
+		},
+		{
+			name:        "systemd unit is a slice",
+			cgroupsPath: "kubepods.slice:cri-containerd:kubepods-pod1234.slice",
+			want:        "/sys/fs/cgroup/kubepods.slice/kubepods-pod1234.slice",
+		},
+		{
+			name:        "systemd invalid slice",
+			cgroupsPath: "kubepods--pod1234.slice:cri-containerd:abc",
+			wantErr:     true,
+		},
+		{
+			name:    "no cgroups path",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := cgroupDir("/sys/fs/cgroup", tt.cgroupsPath)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("cgroupDir(%q) error = %v, want error %v", tt.cgroupsPath, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("cgroupDir(%q) = %q, want %q", tt.cgroupsPath, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCheckDrift(t *testing.T) {
+	pageSize := os.Getpagesize()
+
+	tests := []struct {
+		name      string
+		unified   map[string]string
+		files     map[string]string
+		wantDrift bool
+	}{
+		{
+			name:    "io.max limits are a subset",
+			unified: map[string]string{"io.max": "8:0 rbps=1048576"},
+			files: map[string]string{
+				"io.max": "8:0 rbps=1048576 wbps=max riops=max wiops=max\n259:0 rbps=max wbps=2097152 riops=max wiops=max\n",
+			},
+		},
+		{
+			name:    "io.max unlimited device is not listed",
+			unified: map[string]string{"io.max": "8:16 wbps=max"},
+			files:   map[string]string{"io.max": ""},
+		},
+		{
+			name:      "io.max limit changed",
+			unified:   map[string]string{"io.max": "8:0 rbps=1048576"},
+			files:     map[string]string{"io.max": "8:0 rbps=2097152 wbps=max riops=max wiops=max\n"},
+			wantDrift: true,
+		},
+		{
+			name:    "io.weight bare value is the default",
+			unified: map[string]string{"io.weight": "100"},
+			files:   map[string]string{"io.weight": "default 100\n8:0 200\n"},
+		},
+		{
+			name:    "io.weight default",
+			unified: map[string]string{"io.weight": "default 100"},
+			files:   map[string]string{"io.weight": "default 100\n"},
+		},
+		{
+			name:      "io.weight default changed",
+			unified:   map[string]string{"io.weight": "default 100"},
+			files:     map[string]string{"io.weight": "default 200\n8:0 100\n"},
+			wantDrift: true,
+		},
+		{
+			name:    "memory rounded to the page size",
+			unified: map[string]string{"memory.high": strconv.Itoa(10*pageSize + 1)},
+			files:   map[string]string{"memory.high": strconv.Itoa(10*pageSize) + "\n"},
+		},
+		{
+			name:      "memory limit changed",
+			unified:   map[string]string{"memory.high": strconv.Itoa(10 * pageSize)},
+			files:     map[string]string{"memory.high": "max\n"},
+			wantDrift: true,
+		},
+		{
+			name:    "cpu.max without period",
+			unified: map[string]string{"cpu.max": "50000"},
+			files:   map[string]string{"cpu.max": "50000 100000\n"},
+		},
+		{
+			name:    "missing file is skipped",
+			unified: map[string]string{"pids.max": "100"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root := t.TempDir()
+			container := &api.Container{
+				Id:   "container-id",
+				Name: "ctr0",
+				Linux: &api.LinuxContainer{
+					CgroupsPath: "kubepods-burstable-pod1234.slice:cri-containerd:container-id",
+				},
+			}
+			dir := filepath.Join(root, "kubepods.slice", "kubepods-burstable.slice", "kubepods-burstable-pod1234.slice", "cri-containerd-container-id.scope")
+			if err := os.MkdirAll(dir, 0755); err != nil {
+				t.Fatal(err)
+			}
+			for name, content := range tt.files {
+				if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			fake := &fakeStub{}
+			recorder := record.NewFakeRecorder(10)
+			p := &Plugin{stub: fake, cgroup2: true, cgroupRoot: root, reapplyDrift: true, recorder: recorder}
+			spec := &configapi.RuntimeSpec{
+				Linux: &configapi.Linux{Resources: &configapi.LinuxResources{Unified: tt.unified}},
+			}
+			p.track(&api.PodSandbox{Namespace: "team-a", Name: "test"}, container, spec)
+
+			// Drift is reapplied on every check, but reported once.
+			p.checkDrift()
+			p.checkDrift()
+
+			wantEvents := 0
+			if tt.wantDrift {
+				wantEvents = 1
+			}
+			if got := len(recorder.Events); got != wantEvents {
+				t.Errorf("recorded %d events, want %d", got, wantEvents)
+			}
+			if drifted := len(fake.updates) > 0; drifted != tt.wantDrift {
+				t.Fatalf("reapplied %v, want drift %v", fake.updates, tt.wantDrift)
+			}
+			if tt.wantDrift {
+				update := fake.updates[0]
+				if update.GetContainerId() != container.Id {
+					t.Errorf("update for container %q, want %q", update.GetContainerId(), container.Id)
+				}
+				for key, want := range tt.unified {
+					if got := update.GetLinux().GetResources().GetUnified()[key]; got != want {
+						t.Errorf("reapplied %s = %q, want %q", key, got, want)
+					}
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/containerd/nri/pkg/api"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	coreclientset "k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/scheme"
+	typedcorev1 "k8s.io/client-go/kubernetes/typed/core/v1"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/clientcmd"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/klog/v2"
+)
+
+// newEventBroadcaster returns a broadcaster recording events to the API server
+// reached through kubeconfig or, if it is empty, the in-cluster config.
+func newEventBroadcaster(ctx context.Context, kubeconfig string) (record.EventBroadcaster, error) {
+	var config *rest.Config
+	var err error
+	if kubeconfig == "" {
+		config, err = rest.InClusterConfig()
+	} else {
+		config, err = clientcmd.BuildConfigFromFlags("", kubeconfig)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("create client configuration: %w", err)
+	}
+	client, err := coreclientset.NewForConfig(config)
+	if err != nil {
+		return nil, fmt.Errorf("create core client: %w", err)
+	}
+
+	broadcaster := record.NewBroadcaster(record.WithContext(ctx))
+	broadcaster.StartRecordingToSink(&typedcorev1.EventSinkImpl{
+		Interface: client.CoreV1().Events(""),
+	})
+	return broadcaster, nil
+}
+
+// newEventRecorder returns a recorder for events from the plugin on nodeName.
+func newEventRecorder(broadcaster record.EventBroadcaster, pluginName, nodeName string) record.EventRecorder {
+	return broadcaster.NewRecorder(scheme.Scheme, corev1.EventSource{
+		Component: pluginName,
+		Host:      nodeName,
+	})
+}
+
+// podEvent records an event for pod if the plugin has an event recorder.
+func (p *Plugin) podEvent(pod *api.PodSandbox, eventType, reason, messageFmt string, args ...any) {
+	if p.recorder == nil {
+		return
+	}
+	ref := &corev1.ObjectReference{
+		Kind:       "Pod",
+		APIVersion: "v1",
+		Namespace:  pod.GetNamespace(),
+		Name:       pod.GetName(),
+		UID:        types.UID(pod.GetUid()),
+	}
+	p.recorder.Eventf(ref, eventType, reason, messageFmt, args...)
+	klog.V(3).Infof("Recorded %s event for pod %s/%s", reason, pod.GetNamespace(), pod.GetName())
+}
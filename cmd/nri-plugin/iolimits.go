@@ -0,0 +1,83 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+
+	"golang.org/x/sys/unix"
+
+	configapi "runtime-spec-dra-driver/api/v1alpha1"
+)
+
+// sysBlockDevPath is where the kernel exposes block devices by number.
+const sysBlockDevPath = "/sys/dev/block"
+
+// resolveIOLimits returns the spec of config with its IOLimits resolved to the
+// device numbers on this node and added as blockIO throttles. They are then
+// applied as io.max together with any explicit io.max value.
+func resolveIOLimits(config *configapi.RuntimeSpecEditConfig) (*configapi.RuntimeSpec, error) {
+	if len(config.IOLimits) == 0 {
+		return config.Spec, nil
+	}
+
+	spec := config.Spec.DeepCopy()
+	if spec.Linux == nil {
+		spec.Linux = &configapi.Linux{}
+	}
+	if spec.Linux.Resources == nil {
+		spec.Linux.Resources = &configapi.LinuxResources{}
+	}
+	if spec.Linux.Resources.BlockIO == nil {
+		spec.Linux.Resources.BlockIO = &configapi.LinuxBlockIO{}
+	}
+	blockIO := spec.Linux.Resources.BlockIO
+
+	for _, l := range config.IOLimits {
+		major, minor, err := blockDeviceNumber(l.Device)
+		if err != nil {
+			return nil, fmt.Errorf("unable to resolve ioLimits device: %w", err)
+		}
+
+		throttles := []struct {
+			name    string
+			devices *[]configapi.LinuxThrottleDevice
+			rate    *uint64
+		}{
+			{"throttleReadBpsDevice", &blockIO.ThrottleReadBpsDevice, l.Rbps},
+			{"throttleWriteBpsDevice", &blockIO.ThrottleWriteBpsDevice, l.Wbps},
+			{"throttleReadIOPSDevice", &blockIO.ThrottleReadIOPSDevice, l.Riops},
+			{"throttleWriteIOPSDevice", &blockIO.ThrottleWriteIOPSDevice, l.Wiops},
+		}
+		for _, t := range throttles {
+			if t.rate == nil {
+				continue
+			}
+			if slices.ContainsFunc(*t.devices, func(d configapi.LinuxThrottleDevice) bool { return d.Major == major && d.Minor == minor }) {
+				return nil, fmt.Errorf("ioLimits for %s (%d:%d) conflicts with linux.resources.blockIO.%s", l.Device, major, minor, t.name)
+			}
+			*t.devices = append(*t.devices, configapi.LinuxThrottleDevice{Major: major, Minor: minor, Rate: *t.rate})
+		}
+	}
+
+	return spec, nil
+}
+
+// blockDeviceNumber returns the major and minor number of the whole-disk
+// block device at path. io.max cannot be set for partitions.
+func blockDeviceNumber(path string) (int64, int64, error) {
+	var st unix.Stat_t
+	if err := unix.Stat(path, &st); err != nil {
+		return 0, 0, fmt.Errorf("device %s: %w", path, err)
+	}
+	if st.Mode&unix.S_IFMT != unix.S_IFBLK {
+		return 0, 0, fmt.Errorf("device %s is not a block device", path)
+	}
+
+	major, minor := int64(unix.Major(uint64(st.Rdev))), int64(unix.Minor(uint64(st.Rdev)))
+	if _, err := os.Stat(filepath.Join(sysBlockDevPath, fmt.Sprintf("%d:%d", major, minor), "partition")); err == nil {
+		return 0, 0, fmt.Errorf("device %s is a partition, io limits can only be set for whole disks", path)
+	}
+	return major, minor, nil
+}
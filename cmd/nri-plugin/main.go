@@ -7,9 +7,14 @@ import (
 	"os"
 	"os/signal"
 	"syscall"
+	"time"
 
 	"github.com/containerd/nri/pkg/stub"
 	"k8s.io/klog/v2"
+
+	configapi "runtime-spec-dra-driver/api/v1alpha1"
+	"runtime-spec-dra-driver/pkg/handoff"
+	"runtime-spec-dra-driver/pkg/policy"
 )
 
 const (
@@ -17,6 +22,17 @@ const (
 	PluginName = "runtime-spec-dra"
 	// PluginIdx is the index of this plugin (determines order of execution)
 	PluginIdx = "10"
+	// SpecDir is the directory the DRA plugin writes per-claim config files to
+	SpecDir = "/var/lib/kubelet/plugins/runtime-spec.io/" + handoff.DirName
+	// SeccompRoot is the directory seccomp profile references are resolved
+	// in. It is the kubelet's root for localhost seccomp profiles.
+	SeccompRoot = "/var/lib/kubelet/seccomp"
+	// UpdateInterval is how often config files are checked for edits made on
+	// the node to apply to running containers
+	UpdateInterval = 10 * time.Second
+	// DriftCheckInterval is how often the cgroup files of adjusted containers
+	// are compared with the applied settings
+	DriftCheckInterval = time.Minute
 )
 
 var (
@@ -25,21 +41,82 @@ var (
 
 func main() {
 	var (
-		pluginName string
-		pluginIdx  string
-		socketPath string
+		pluginName     string
+		pluginIdx      string
+		socketPath     string
+		policyFile     string
+		keepEnv        bool
+		allowInline    bool
+		specDir        string
+		seccompRoot    string
+		updateInterval time.Duration
+		cgroupRoot     string
+		driftInterval  time.Duration
+		reapplyDrift   bool
+		metricsAddress string
+		mode           = ModeEnforce
+		failurePolicy  string
+		kubeconfig     string
+		nodeName       string
 	)
 
 	flag.StringVar(&pluginName, "name", PluginName, "plugin name to register with NRI")
 	flag.StringVar(&pluginIdx, "idx", PluginIdx, "plugin index to register with NRI")
 	flag.StringVar(&socketPath, "socket", "", "NRI socket path to connect to")
+	flag.StringVar(&policyFile, "policy-file", "", "node policy file restricting which runtime spec fields may be applied")
+	flag.StringVar(&specDir, "spec-dir", SpecDir, "directory the DRA plugin writes per-claim config files to")
+	flag.StringVar(&seccompRoot, "seccomp-root", SeccompRoot, "directory seccomp profiles referenced by claims are loaded from")
+	flag.DurationVar(&updateInterval, "update-interval", UpdateInterval, "how often to check config files for edits made on the node to apply to running containers, 0 disables updates")
+	flag.StringVar(&cgroupRoot, "cgroup-root", CgroupRoot, "where the host's cgroup hierarchy is mounted")
+	flag.DurationVar(&driftInterval, "drift-check-interval", DriftCheckInterval, "how often to compare the cgroup files of adjusted containers with the applied settings, 0 disables the check")
+	flag.BoolVar(&reapplyDrift, "reapply-drift", false, "reapply the settings of containers whose cgroup files differ from them")
+	flag.StringVar(&metricsAddress, "metrics-address", "", "address to serve metrics on, e.g. :8080, empty disables metrics")
+	flag.Var(&mode, "mode", fmt.Sprintf("what to do with adjustments, one of %v: enforce applies them and fails container creation on errors, warn applies what can be translated and logs the rest, audit only logs them", Modes))
+	flag.StringVar(&failurePolicy, "failure-policy", string(configapi.FailurePolicyFailClosed), fmt.Sprintf("whether a container whose spec cannot be applied is not created (%s) or created without it (%s), unless its configs override it", configapi.FailurePolicyFailClosed, configapi.FailurePolicyFailOpen))
+	flag.StringVar(&kubeconfig, "kubeconfig", os.Getenv("KUBECONFIG"), "kubeconfig file used to record pod events, the in-cluster config is used if empty")
+	flag.StringVar(&nodeName, "node-name", os.Getenv("NODE_NAME"), "name of the node, reported as the source of pod events")
+	flag.BoolVar(&allowInline, "allow-inline-specs", false, fmt.Sprintf("apply specs from the %s annotation and the %s environment variable; pod authors set these directly, so they bypass namespace policies and are only checked against the node policy", AnnotationKeyConfig, EnvKeyOCIRuntimeSpec))
+	flag.BoolVar(&keepEnv, "keep-spec-env", false, "keep the OCI_RUNTIME_SPEC(_REF) environment variable in the container (for debugging)")
 
 	klog.InitFlags(nil)
 	flag.Parse()
 
-	klog.Infof("Starting %s NRI plugin version %s", pluginName, version)
+	klog.Infof("Starting %s NRI plugin version %s in %s mode", pluginName, version, mode)
+
+	switch configapi.FailurePolicy(failurePolicy) {
+	case configapi.FailurePolicyFailClosed, configapi.FailurePolicyFailOpen:
+	default:
+		klog.Fatalf("Unsupported failure policy %q, supported policies are %s and %s", failurePolicy, configapi.FailurePolicyFailClosed, configapi.FailurePolicyFailOpen)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	plugin := &Plugin{
+		specDir:          specDir,
+		seccompRoot:      seccompRoot,
+		keepSpecEnv:      keepEnv,
+		allowInlineSpecs: allowInline,
+		cgroupRoot:       cgroupRoot,
+		reapplyDrift:     reapplyDrift,
+		mode:             mode,
+		failurePolicy:    configapi.FailurePolicy(failurePolicy),
+	}
+	if policyFile != "" {
+		p, err := policy.Load(policyFile)
+		if err != nil {
+			klog.Fatalf("Failed to load node policy: %v", err)
+		}
+		plugin.policy = p
+	}
 
-	plugin := &Plugin{}
+	broadcaster, err := newEventBroadcaster(ctx, kubeconfig)
+	if err != nil {
+		klog.Warningf("Pod events are disabled: %v", err)
+	} else {
+		defer broadcaster.Shutdown()
+		plugin.recorder = newEventRecorder(broadcaster, pluginName, nodeName)
+	}
 
 	opts := []stub.Option{
 		stub.WithPluginName(pluginName),
@@ -49,15 +126,11 @@ func main() {
 		opts = append(opts, stub.WithSocketPath(socketPath))
 	}
 
-	var err error
 	plugin.stub, err = stub.New(plugin, opts...)
 	if err != nil {
 		klog.Fatalf("Failed to create NRI stub: %v", err)
 	}
 
-	ctx, cancel := context.WithCancel(context.Background())
-	defer cancel()
-
 	// Handle shutdown signals
 	sigCh := make(chan os.Signal, 1)
 	signal.Notify(sigCh, syscall.SIGINT, syscall.SIGTERM)
@@ -68,6 +141,16 @@ func main() {
 		cancel()
 	}()
 
+	if updateInterval > 0 {
+		go plugin.watchConfigs(ctx, updateInterval)
+	}
+	if driftInterval > 0 {
+		go plugin.watchCgroups(ctx, driftInterval)
+	}
+	if metricsAddress != "" {
+		go serveMetrics(metricsAddress)
+	}
+
 	err = plugin.stub.Run(ctx)
 	if err != nil {
 		klog.Fatalf("Plugin exited with error: %v", err)
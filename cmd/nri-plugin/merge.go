@@ -0,0 +1,313 @@
+package main
+
+import (
+	"fmt"
+	"maps"
+	"reflect"
+	"slices"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/util/validation/field"
+
+	configapi "runtime-spec-dra-driver/api/v1alpha1"
+)
+
+// sourcedSpec is a runtime spec together with the reference it was read from.
+type sourcedSpec struct {
+	source string
+	spec   *configapi.RuntimeSpec
+}
+
+// specMerger combines the runtime specs of all claims referenced by a
+// container into a single spec. Settings that are identical across specs are
+// deduplicated, list entries are concatenated, and two specs setting the same
+// value (e.g. the same unified key or mount destination) differently is
+// reported as a conflict naming both sources.
+type specMerger struct {
+	spec    *configapi.RuntimeSpec
+	origins map[string]string
+	errs    field.ErrorList
+}
+
+// mergeRuntimeSpecs merges specs in the given order. Callers should order
+// specs deterministically so that list entries are always applied the same way.
+func mergeRuntimeSpecs(specs []sourcedSpec) (*configapi.RuntimeSpec, error) {
+	if len(specs) == 1 {
+		return specs[0].spec, nil
+	}
+
+	m := &specMerger{
+		spec:    &configapi.RuntimeSpec{},
+		origins: make(map[string]string),
+	}
+	for _, s := range specs {
+		m.merge(s.source, s.spec)
+	}
+	if len(m.errs) > 0 {
+		return nil, fmt.Errorf("conflicting runtime specs: %w", m.errs.ToAggregate())
+	}
+	return m.spec, nil
+}
+
+func (m *specMerger) merge(source string, spec *configapi.RuntimeSpec) {
+	if spec == nil {
+		return
+	}
+	fldPath := field.NewPath("spec")
+
+	if spec.Process != nil {
+		if m.spec.Process == nil {
+			m.spec.Process = &configapi.Process{}
+		}
+		mergeKeyed(m, fldPath.Child("process", "env"), source, &m.spec.Process.Env, spec.Process.Env, envName)
+		mergeKeyed(m, fldPath.Child("process", "rlimits"), source, &m.spec.Process.Rlimits, spec.Process.Rlimits, func(r configapi.POSIXRlimit) string { return r.Type })
+		mergePtr(m, fldPath.Child("process", "oomScoreAdj"), source, &m.spec.Process.OOMScoreAdj, spec.Process.OOMScoreAdj)
+		// The remaining process fields are rejected by validation, but are
+		// still carried over so the merged spec reports them.
+		mergeObject(m, fldPath.Child("process", "capabilities"), source, &m.spec.Process.Capabilities, spec.Process.Capabilities)
+		mergeValue(m, fldPath.Child("process", "noNewPrivileges"), source, &m.spec.Process.NoNewPrivileges, spec.Process.NoNewPrivileges)
+		mergeValue(m, fldPath.Child("process", "apparmorProfile"), source, &m.spec.Process.ApparmorProfile, spec.Process.ApparmorProfile)
+		mergeValue(m, fldPath.Child("process", "selinuxLabel"), source, &m.spec.Process.SelinuxLabel, spec.Process.SelinuxLabel)
+	}
+
+	mergeKeyed(m, fldPath.Child("mounts"), source, &m.spec.Mounts, spec.Mounts, func(mnt configapi.Mount) string { return mnt.Destination })
+
+	if spec.Hooks != nil {
+		if m.spec.Hooks == nil {
+			m.spec.Hooks = &configapi.Hooks{}
+		}
+		dst, src := m.spec.Hooks, spec.Hooks
+		mergeUnique(&dst.Prestart, src.Prestart)
+		mergeUnique(&dst.CreateRuntime, src.CreateRuntime)
+		mergeUnique(&dst.CreateContainer, src.CreateContainer)
+		mergeUnique(&dst.StartContainer, src.StartContainer)
+		mergeUnique(&dst.Poststart, src.Poststart)
+		mergeUnique(&dst.Poststop, src.Poststop)
+	}
+
+	if spec.Linux != nil {
+		if m.spec.Linux == nil {
+			m.spec.Linux = &configapi.Linux{}
+		}
+		m.mergeLinux(fldPath.Child("linux"), source, m.spec.Linux, spec.Linux)
+	}
+
+	if len(spec.Annotations) > 0 {
+		if m.spec.Annotations == nil {
+			m.spec.Annotations = make(map[string]string)
+		}
+		for _, key := range slices.Sorted(maps.Keys(spec.Annotations)) {
+			mergeMapValue(m, fldPath.Child("annotations").Key(key), source, m.spec.Annotations, key, spec.Annotations[key])
+		}
+	}
+}
+
+func (m *specMerger) mergeLinux(fldPath *field.Path, source string, dst, src *configapi.Linux) {
+	mergeKeyed(m, fldPath.Child("devices"), source, &dst.Devices, src.Devices, func(d configapi.LinuxDevice) string { return d.Path })
+	mergeKeyed(m, fldPath.Child("namespaces"), source, &dst.Namespaces, src.Namespaces, func(ns configapi.LinuxNamespace) string { return ns.Type })
+
+	if len(src.Sysctl) > 0 {
+		if dst.Sysctl == nil {
+			dst.Sysctl = make(map[string]string)
+		}
+		for _, key := range slices.Sorted(maps.Keys(src.Sysctl)) {
+			mergeMapValue(m, fldPath.Child("sysctl").Key(key), source, dst.Sysctl, key, src.Sysctl[key])
+		}
+	}
+
+	if src.IntelRdt != nil {
+		if dst.IntelRdt == nil {
+			dst.IntelRdt = &configapi.LinuxIntelRdt{}
+		}
+		mergeValue(m, fldPath.Child("intelRdt", "closID"), source, &dst.IntelRdt.ClosID, src.IntelRdt.ClosID)
+	}
+
+	mergeObject(m, fldPath.Child("seccomp"), source, &dst.Seccomp, src.Seccomp)
+
+	if src.Resources == nil {
+		return
+	}
+	if dst.Resources == nil {
+		dst.Resources = &configapi.LinuxResources{}
+	}
+	res, srcRes := dst.Resources, src.Resources
+	resPath := fldPath.Child("resources")
+
+	if srcRes.Memory != nil {
+		if res.Memory == nil {
+			res.Memory = &configapi.LinuxMemory{}
+		}
+		memPath := resPath.Child("memory")
+		mergePtr(m, memPath.Child("limit"), source, &res.Memory.Limit, srcRes.Memory.Limit)
+		mergePtr(m, memPath.Child("reservation"), source, &res.Memory.Reservation, srcRes.Memory.Reservation)
+		mergePtr(m, memPath.Child("swap"), source, &res.Memory.Swap, srcRes.Memory.Swap)
+		mergePtr(m, memPath.Child("kernel"), source, &res.Memory.Kernel, srcRes.Memory.Kernel)
+		mergePtr(m, memPath.Child("kernelTCP"), source, &res.Memory.KernelTCP, srcRes.Memory.KernelTCP)
+		mergePtr(m, memPath.Child("swappiness"), source, &res.Memory.Swappiness, srcRes.Memory.Swappiness)
+		mergePtr(m, memPath.Child("disableOOMKiller"), source, &res.Memory.DisableOOMKiller, srcRes.Memory.DisableOOMKiller)
+		mergePtr(m, memPath.Child("useHierarchy"), source, &res.Memory.UseHierarchy, srcRes.Memory.UseHierarchy)
+		mergePtr(m, memPath.Child("checkBeforeUpdate"), source, &res.Memory.CheckBeforeUpdate, srcRes.Memory.CheckBeforeUpdate)
+	}
+
+	if srcRes.CPU != nil {
+		if res.CPU == nil {
+			res.CPU = &configapi.LinuxCPU{}
+		}
+		cpuPath := resPath.Child("cpu")
+		mergePtr(m, cpuPath.Child("shares"), source, &res.CPU.Shares, srcRes.CPU.Shares)
+		mergePtr(m, cpuPath.Child("quota"), source, &res.CPU.Quota, srcRes.CPU.Quota)
+		mergePtr(m, cpuPath.Child("burst"), source, &res.CPU.Burst, srcRes.CPU.Burst)
+		mergePtr(m, cpuPath.Child("period"), source, &res.CPU.Period, srcRes.CPU.Period)
+		mergePtr(m, cpuPath.Child("realtimeRuntime"), source, &res.CPU.RealtimeRuntime, srcRes.CPU.RealtimeRuntime)
+		mergePtr(m, cpuPath.Child("realtimePeriod"), source, &res.CPU.RealtimePeriod, srcRes.CPU.RealtimePeriod)
+		mergeValue(m, cpuPath.Child("cpus"), source, &res.CPU.Cpus, srcRes.CPU.Cpus)
+		mergeValue(m, cpuPath.Child("mems"), source, &res.CPU.Mems, srcRes.CPU.Mems)
+		mergePtr(m, cpuPath.Child("idle"), source, &res.CPU.Idle, srcRes.CPU.Idle)
+	}
+
+	if srcRes.BlockIO != nil {
+		if res.BlockIO == nil {
+			res.BlockIO = &configapi.LinuxBlockIO{}
+		}
+		blkioPath := resPath.Child("blockIO")
+		dst, src := res.BlockIO, srcRes.BlockIO
+		mergePtr(m, blkioPath.Child("weight"), source, &dst.Weight, src.Weight)
+		mergeKeyed(m, blkioPath.Child("weightDevice"), source, &dst.WeightDevice, src.WeightDevice, func(d configapi.LinuxWeightDevice) string { return blockDevice(d.Major, d.Minor) })
+		mergeKeyed(m, blkioPath.Child("throttleReadBpsDevice"), source, &dst.ThrottleReadBpsDevice, src.ThrottleReadBpsDevice, throttleDevice)
+		mergeKeyed(m, blkioPath.Child("throttleWriteBpsDevice"), source, &dst.ThrottleWriteBpsDevice, src.ThrottleWriteBpsDevice, throttleDevice)
+		mergeKeyed(m, blkioPath.Child("throttleReadIOPSDevice"), source, &dst.ThrottleReadIOPSDevice, src.ThrottleReadIOPSDevice, throttleDevice)
+		mergeKeyed(m, blkioPath.Child("throttleWriteIOPSDevice"), source, &dst.ThrottleWriteIOPSDevice, src.ThrottleWriteIOPSDevice, throttleDevice)
+	}
+
+	if srcRes.Pids != nil {
+		if res.Pids == nil {
+			res.Pids = &configapi.LinuxPids{}
+		}
+		mergeValue(m, resPath.Child("pids", "limit"), source, &res.Pids.Limit, srcRes.Pids.Limit)
+	}
+
+	mergeKeyed(m, resPath.Child("hugepageLimits"), source, &res.HugepageLimits, srcRes.HugepageLimits, func(h configapi.LinuxHugepageLimit) string { return h.Pagesize })
+
+	if len(srcRes.Unified) > 0 {
+		if res.Unified == nil {
+			res.Unified = make(map[string]string)
+		}
+		for _, key := range slices.Sorted(maps.Keys(srcRes.Unified)) {
+			v := srcRes.Unified[key]
+			mergeMapValue(m, resPath.Child("unified").Key(key), source, res.Unified, key, v)
+		}
+	}
+}
+
+// conflict records that source sets fldPath to value although an earlier
+// source already set it to something else.
+func (m *specMerger) conflict(fldPath *field.Path, source string, value any) {
+	m.errs = append(m.errs, field.Invalid(fldPath, value, fmt.Sprintf("set by %s conflicts with the value set by %s", source, m.origins[fldPath.String()])))
+}
+
+// mergeValue merges a scalar where the zero value means unset.
+func mergeValue[T comparable](m *specMerger, fldPath *field.Path, source string, dst *T, src T) {
+	var zero T
+	switch {
+	case src == zero:
+	case *dst == zero:
+		*dst = src
+		m.origins[fldPath.String()] = source
+	case *dst != src:
+		m.conflict(fldPath, source, src)
+	}
+}
+
+// mergePtr merges an optional scalar.
+func mergePtr[T comparable](m *specMerger, fldPath *field.Path, source string, dst **T, src *T) {
+	switch {
+	case src == nil:
+	case *dst == nil:
+		v := *src
+		*dst = &v
+		m.origins[fldPath.String()] = source
+	case **dst != *src:
+		m.conflict(fldPath, source, *src)
+	}
+}
+
+// mergeObject merges an optional struct that must be identical in all specs
+// setting it.
+func mergeObject[T any](m *specMerger, fldPath *field.Path, source string, dst **T, src *T) {
+	switch {
+	case src == nil:
+	case *dst == nil:
+		*dst = src
+		m.origins[fldPath.String()] = source
+	case !reflect.DeepEqual(*dst, src):
+		m.conflict(fldPath, source, field.OmitValueType{})
+	}
+}
+
+func mergeMapValue(m *specMerger, fldPath *field.Path, source string, dst map[string]string, key, value string) {
+	existing, ok := dst[key]
+	switch {
+	case !ok:
+		dst[key] = value
+		m.origins[fldPath.String()] = source
+	case existing != value:
+		m.conflict(fldPath, source, value)
+	}
+}
+
+// mergeKeyed merges list entries identified by key. Entries with a new key are
+// appended, identical entries are dropped and differing entries for the same
+// key are reported as a conflict.
+func mergeKeyed[T any](m *specMerger, fldPath *field.Path, source string, dst *[]T, src []T, key func(T) string) {
+	for _, item := range src {
+		k := key(item)
+		idx := slices.IndexFunc(*dst, func(existing T) bool { return key(existing) == k })
+		switch {
+		case idx < 0:
+			*dst = append(*dst, item)
+			m.origins[fldPath.Key(k).String()] = source
+		case !reflect.DeepEqual((*dst)[idx], item):
+			m.conflict(fldPath.Key(k), source, field.OmitValueType{})
+		}
+	}
+}
+
+// mergeRemovals returns the union of dst and src. Removals cannot conflict,
+// so there is nothing to report.
+func mergeRemovals(dst, src *configapi.Removals) *configapi.Removals {
+	if src == nil {
+		return dst
+	}
+	if dst == nil {
+		dst = &configapi.Removals{}
+	}
+	mergeUnique(&dst.Env, src.Env)
+	mergeUnique(&dst.Mounts, src.Mounts)
+	mergeUnique(&dst.Devices, src.Devices)
+	mergeUnique(&dst.Annotations, src.Annotations)
+	return dst
+}
+
+// mergeUnique appends the entries of src that are not already in dst.
+func mergeUnique[T any](dst *[]T, src []T) {
+	for _, item := range src {
+		if !slices.ContainsFunc(*dst, func(existing T) bool { return reflect.DeepEqual(existing, item) }) {
+			*dst = append(*dst, item)
+		}
+	}
+}
+
+// blockDevice formats a <major>:<minor> device number.
+func blockDevice(major, minor int64) string {
+	return fmt.Sprintf("%d:%d", major, minor)
+}
+
+func throttleDevice(d configapi.LinuxThrottleDevice) string {
+	return blockDevice(d.Major, d.Minor)
+}
+
+// envName returns the name of a KEY=VALUE environment entry.
+func envName(env string) string {
+	name, _, _ := strings.Cut(env, "=")
+	return name
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"net/http"
+
+	"k8s.io/component-base/metrics"
+	"k8s.io/component-base/metrics/legacyregistry"
+	"k8s.io/klog/v2"
+)
+
+const metricsNamespace = "runtime_spec_nri"
+
+var (
+	cgroupDriftTotal = metrics.NewCounterVec(
+		&metrics.CounterOpts{
+			Namespace:      metricsNamespace,
+			Name:           "cgroup_drift_total",
+			Help:           "Number of times a container's cgroup file was found not to match the unified parameter applied to it, by parameter.",
+			StabilityLevel: metrics.ALPHA,
+		},
+		[]string{"key"},
+	)
+	cgroupDriftContainers = metrics.NewGauge(
+		&metrics.GaugeOpts{
+			Namespace:      metricsNamespace,
+			Name:           "cgroup_drift_containers",
+			Help:           "Number of containers whose cgroup files did not match their unified parameters in the last check.",
+			StabilityLevel: metrics.ALPHA,
+		},
+	)
+	cgroupDriftReappliedTotal = metrics.NewCounter(
+		&metrics.CounterOpts{
+			Namespace:      metricsNamespace,
+			Name:           "cgroup_drift_reapplied_total",
+			Help:           "Number of containers whose settings were reapplied after drift was detected.",
+			StabilityLevel: metrics.ALPHA,
+		},
+	)
+)
+
+func init() {
+	legacyregistry.MustRegister(cgroupDriftTotal, cgroupDriftContainers, cgroupDriftReappliedTotal)
+}
+
+// serveMetrics serves the registered metrics on address under /metrics.
+func serveMetrics(address string) {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", legacyregistry.Handler())
+	klog.Infof("Serving metrics on %s", address)
+	if err := http.ListenAndServe(address, mux); err != nil {
+		klog.Errorf("Failed to serve metrics: %v", err)
+	}
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/containerd/nri/pkg/api"
+	"k8s.io/klog/v2"
+)
+
+// Mode decides what the plugin does with the adjustments it computes.
+type Mode string
+
+const (
+	// ModeEnforce applies adjustments and fails container creation if a
+	// spec cannot be applied.
+	ModeEnforce Mode = "enforce"
+	// ModeWarn applies adjustments but only logs the settings that cannot be
+	// applied on this host, applying the remaining ones: ioLimits whose
+	// device cannot be resolved, seccomp profile references that cannot be
+	// loaded, on cgroup v1 hosts, unified parameters without a v1 equivalent
+	// and settings that cannot be translated to an NRI adjustment. Specs that
+	// are invalid, conflict or are denied by policy still fail container
+	// creation.
+	ModeWarn Mode = "warn"
+	// ModeAudit only logs what would be applied, or why container creation
+	// would fail, and leaves containers unchanged.
+	ModeAudit Mode = "audit"
+)
+
+// Modes are the supported modes.
+var Modes = []Mode{ModeEnforce, ModeWarn, ModeAudit}
+
+// String implements flag.Value.
+func (m *Mode) String() string {
+	return string(*m)
+}
+
+// Set implements flag.Value.
+func (m *Mode) Set(value string) error {
+	for _, mode := range Modes {
+		if Mode(value) == mode {
+			*m = mode
+			return nil
+		}
+	}
+	return fmt.Errorf("unsupported mode %q, supported modes are %v", value, Modes)
+}
+
+// auditAdjustment logs a structured record of the adjustment that would be
+// applied to container, or of the error that would fail its creation.
+func auditAdjustment(pod *api.PodSandbox, container *api.Container, adjustment *api.ContainerAdjustment, err error) {
+	kv := []any{
+		"pod", klog.KRef(pod.GetNamespace(), pod.GetName()),
+		"container", container.GetName(),
+		"containerID", container.GetId(),
+	}
+	switch {
+	case err != nil:
+		klog.InfoS("Audit: container creation would fail", append(kv, "err", err.Error())...)
+	case adjustment != nil:
+		klog.InfoS("Audit: container would be adjusted", append(kv, "adjustment", adjustment)...)
+	}
+}
+
+// auditUpdate logs a structured record of the update that would be applied to
+// a running container.
+func auditUpdate(pod *api.PodSandbox, container *api.Container, update *api.ContainerUpdate) {
+	klog.InfoS("Audit: container would be updated",
+		"pod", klog.KRef(pod.GetNamespace(), pod.GetName()),
+		"container", container.GetName(),
+		"containerID", container.GetId(),
+		"update", update,
+	)
+}
@@ -2,45 +2,157 @@ package main
 
 import (
 	"context"
-	"encoding/json"
+	"errors"
 	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"sync"
 
 	"github.com/containerd/nri/pkg/api"
 	"github.com/containerd/nri/pkg/stub"
-	spec "github.com/opencontainers/runtime-spec/specs-go"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"k8s.io/client-go/tools/record"
 	"k8s.io/klog/v2"
+
+	configapi "runtime-spec-dra-driver/api/v1alpha1"
+	"runtime-spec-dra-driver/pkg/handoff"
+	"runtime-spec-dra-driver/pkg/policy"
 )
 
 const (
-	// AnnotationKeyConfig is the annotation key for the OCI runtime spec config
-	// The DRA plugin encodes the RuntimeSpecEditConfig.Spec as JSON in this annotation
+	// AnnotationKeyConfig is the annotation key for an inline OCI runtime spec
+	// config, encoded as JSON. Pod authors set it directly, bypassing
+	// namespace policies, so it is only honored with allowInlineSpecs.
 	AnnotationKeyConfig = "nri.runtime-spec.io/config"
 
-	// EnvKeyOCIRuntimeSpec is the environment variable key used by the DRA plugin
-	// to pass the OCI runtime spec configuration via CDI container edits
+	// AnnotationKeyRefs is the container annotation recording the config file
+	// references consumed in CreateContainer, comma separated. It allows finding
+	// the configs of running containers after the references have been removed
+	// from their environment.
+	AnnotationKeyRefs = "nri.runtime-spec.io/refs"
+
+	// EnvKeyOCIRuntimeSpec is the environment variable key used by earlier
+	// versions of the DRA plugin to pass the OCI runtime spec inline via CDI
+	// container edits. Like AnnotationKeyConfig it can be set by pod authors
+	// and is only honored with allowInlineSpecs.
 	EnvKeyOCIRuntimeSpec = "OCI_RUNTIME_SPEC"
 )
 
 // Plugin implements the NRI plugin interface
 type Plugin struct {
-	stub stub.Stub
+	stub   stub.Stub
+	policy *policy.Policy
+
+	// specDir is the directory the DRA plugin writes per-claim config files to.
+	specDir string
+
+	// seccompRoot is the directory seccomp profile references are resolved in.
+	seccompRoot string
+
+	// allowInlineSpecs applies specs from the AnnotationKeyConfig annotation
+	// and the EnvKeyOCIRuntimeSpec environment variable. Neither is checked
+	// against namespace policies, only against the node policy.
+	allowInlineSpecs bool
+
+	// inlineIgnored holds the IDs of the containers whose inline specs were
+	// ignored and reported, so each is reported once.
+	inlineIgnored sync.Map
+
+	// keepSpecEnv leaves OCI_RUNTIME_SPEC and OCI_RUNTIME_SPEC_REF in the
+	// container environment after they have been consumed. This is only
+	// meant for debugging.
+	keepSpecEnv bool
+
+	// cgroup2 is set when the host uses the cgroup v2 unified hierarchy. It
+	// is detected in Configure. On cgroup v1 hosts unified parameters are
+	// converted to their v1 equivalents.
+	cgroup2 bool
+
+	// cgroupRoot is where the host's cgroup hierarchy is mounted in the
+	// plugin's mount namespace.
+	cgroupRoot string
+
+	// reapplyDrift reapplies the live settings of containers whose cgroup
+	// files were found to differ from them.
+	reapplyDrift bool
+
+	// mode decides whether adjustments are applied, see Mode.
+	mode Mode
+
+	// failurePolicy decides whether a container whose spec cannot be applied
+	// is created without it, unless its configs override it.
+	failurePolicy configapi.FailurePolicy
+
+	// recorder records pod events about failed specs. It is nil if the
+	// plugin has no access to the API server.
+	recorder record.EventRecorder
+
+	// index holds the containers adjusted by the plugin.
+	index containerIndex
 }
 
 // Configure is called when the plugin is first registered with NRI
 func (p *Plugin) Configure(_ context.Context, config, runtime, version string) (stub.EventMask, error) {
 	klog.Infof("Configure called: runtime=%s, version=%s", runtime, version)
 
-	// Subscribe to container creation events - this is where we can modify the container spec
+	cgroup2, err := isCgroup2UnifiedMode(p.cgroupRoot)
+	if err != nil {
+		return 0, fmt.Errorf("failed to detect cgroup mode: %w", err)
+	}
+	p.cgroup2 = cgroup2
+	if cgroup2 {
+		klog.Info("Detected cgroup v2 unified hierarchy")
+	} else {
+		klog.Info("Detected cgroup v1 hierarchy, unified parameters will be converted to their cgroup v1 equivalents")
+	}
+
+	// Subscribe to container creation events - this is where we can modify the
+	// container spec - to post-creation events to start tracking the container
+	// and to removals to stop tracking it
 	var mask stub.EventMask
-	mask.Set(api.Event_CREATE_CONTAINER)
+	mask.Set(api.Event_CREATE_CONTAINER, api.Event_POST_CREATE_CONTAINER, api.Event_REMOVE_CONTAINER)
 	return mask, nil
 }
 
 // Synchronize is called after Configure, providing the initial state of pods and containers
 func (p *Plugin) Synchronize(_ context.Context, pods []*api.PodSandbox, containers []*api.Container) ([]*api.ContainerUpdate, error) {
 	klog.Infof("Synchronize called: pods=%d, containers=%d", len(pods), len(containers))
-	// No updates needed for existing containers
-	return nil, nil
+
+	podsByID := make(map[string]*api.PodSandbox, len(pods))
+	for _, pod := range pods {
+		podsByID[pod.GetId()] = pod
+	}
+
+	// Containers may have been created while the plugin was not running, or
+	// their settings changed since, so reapply what can be applied live and
+	// report the rest.
+	var updates []*api.ContainerUpdate
+	for _, container := range containers {
+		if container.GetState() == api.ContainerState_CONTAINER_STOPPED {
+			continue
+		}
+		pod := podsByID[container.GetPodSandboxId()]
+		update, drift, err := p.synchronizeContainer(pod, container)
+		if err != nil {
+			klog.Errorf("Failed to reconcile container %s in pod %s/%s: %v",
+				container.GetName(), pod.GetNamespace(), pod.GetName(), err)
+			continue
+		}
+		if len(drift) > 0 {
+			klog.Warningf("Container %s in pod %s/%s does not match its OCI runtime spec in settings that cannot be updated in a running container, they take effect when it is recreated: %v",
+				container.GetName(), pod.GetNamespace(), pod.GetName(), drift)
+		}
+		if update != nil && p.mode == ModeAudit {
+			auditUpdate(pod, container, update)
+		} else if update != nil {
+			klog.Infof("Reapplying resources to container %s in pod %s/%s: unified=%v",
+				container.GetName(), pod.GetNamespace(), pod.GetName(), update.GetLinux().GetResources().GetUnified())
+			updates = append(updates, update)
+		}
+	}
+	return updates, nil
 }
 
 // Shutdown is called when NRI is shutting down the plugin
@@ -54,33 +166,28 @@ func (p *Plugin) CreateContainer(_ context.Context, pod *api.PodSandbox, contain
 	klog.V(2).Infof("CreateContainer called: pod=%s/%s, container=%s",
 		pod.GetNamespace(), pod.GetName(), container.GetName())
 
-	// Check for config in multiple sources (in order of precedence):
-	// 1. Container annotations (nri.runtime-spec.io/config)
-	// 2. Pod annotations (nri.runtime-spec.io/config)
-	// 3. Container environment variable (OCI_RUNTIME_SPEC) - set by DRA plugin via CDI
-	configJSON := getConfigAnnotation(pod, container)
-	if configJSON == "" {
-		configJSON = getConfigFromEnv(container)
-	}
-	if configJSON == "" {
-		klog.V(3).Infof("No runtime-spec config found for container %s", container.GetName())
+	adjustment, ociSpec, err := p.createContainerAdjustment(pod, container)
+	if p.mode == ModeAudit {
+		auditAdjustment(pod, container, adjustment, err)
 		return nil, nil, nil
 	}
-
-	klog.Infof("Found runtime-spec config for container %s in pod %s/%s", container.GetName(), pod.GetNamespace(), pod.GetName())
-
-	// Parse the OCI runtime spec from the annotation
-	var ociSpec spec.Spec
-	if err := json.Unmarshal([]byte(configJSON), &ociSpec); err != nil {
-		klog.Errorf("Failed to parse OCI runtime spec from annotation: %v", err)
-		return nil, nil, fmt.Errorf("failed to parse OCI runtime spec: %w", err)
+	if err != nil {
+		if p.getFailurePolicy(pod, container) == configapi.FailurePolicyFailOpen {
+			klog.Warningf("Creating container %s in pod %s/%s without its OCI runtime spec, failure policy is %s: %v",
+				container.GetName(), pod.GetNamespace(), pod.GetName(), configapi.FailurePolicyFailOpen, err)
+			p.podEvent(pod, corev1.EventTypeWarning, "RuntimeSpecSkipped",
+				"Container %s created without its runtime spec (failure policy %s): %v", container.GetName(), configapi.FailurePolicyFailOpen, err)
+			return p.stripSpecEnv(nil, container), nil, nil
+		}
+		p.podEvent(pod, corev1.EventTypeWarning, "RuntimeSpecFailed",
+			"Container %s not created, its runtime spec cannot be applied (failure policy %s): %v", container.GetName(), configapi.FailurePolicyFailClosed, err)
+		return nil, nil, err
 	}
 
-	// Create container adjustment based on the OCI spec
-	adjustment, err := createAdjustment(&ociSpec)
-	if err != nil {
-		klog.Errorf("Failed to create container adjustment: %v", err)
-		return nil, nil, fmt.Errorf("failed to create container adjustment: %w", err)
+	// Remember what was applied to pick up later changes of config files and
+	// to detect drift, once the container is created.
+	if ociSpec != nil {
+		p.trackPending(pod, container, ociSpec)
 	}
 
 	if adjustment != nil {
@@ -95,6 +202,257 @@ func (p *Plugin) CreateContainer(_ context.Context, pod *api.PodSandbox, contain
 	return adjustment, nil, nil
 }
 
+// createContainerAdjustment returns the adjustment for a container being
+// created together with the spec it was created from, or nil for both if the
+// container has no runtime spec.
+func (p *Plugin) createContainerAdjustment(pod *api.PodSandbox, container *api.Container) (*api.ContainerAdjustment, *configapi.RuntimeSpec, error) {
+	ociSpec, removals, err := p.getRuntimeSpec(pod, container)
+	if err != nil {
+		klog.Errorf("Failed to get OCI runtime spec for container %s: %v", container.GetName(), err)
+		return nil, nil, fmt.Errorf("failed to get OCI runtime spec: %w", err)
+	}
+	if ociSpec == nil {
+		klog.V(3).Infof("No runtime-spec config found for container %s", container.GetName())
+		return nil, nil, nil
+	}
+
+	klog.Infof("Found runtime-spec config for container %s in pod %s/%s", container.GetName(), pod.GetNamespace(), pod.GetName())
+	ociSpec, err = p.prepareSpec(ociSpec, removals)
+	if err != nil {
+		klog.Errorf("Failed to prepare OCI runtime spec for container %s: %v", container.GetName(), err)
+		return nil, nil, err
+	}
+
+	// Create container adjustment based on the OCI spec
+	adjustment, err := p.translateSpec(container, ociSpec)
+	if err != nil {
+		return nil, nil, err
+	}
+	adjustment = addRemovals(adjustment, removals)
+
+	// Record the config file references for Synchronize after a plugin
+	// restart.
+	if refs := getRefs(container); len(refs) > 0 {
+		if adjustment == nil {
+			adjustment = &api.ContainerAdjustment{}
+		}
+		adjustment.AddAnnotation(AnnotationKeyRefs, strings.Join(refs, ","))
+	}
+
+	return p.stripSpecEnv(adjustment, container), ociSpec, nil
+}
+
+// translateSpec returns the adjustment for ociSpec. Settings that cannot be
+// translated fail the container, except in warn mode, where they are logged
+// and the adjustment for the remaining ones is returned.
+func (p *Plugin) translateSpec(container *api.Container, ociSpec *configapi.RuntimeSpec) (*api.ContainerAdjustment, error) {
+	adjustment, err := createAdjustment(ociSpec)
+	if err == nil {
+		return adjustment, nil
+	}
+	if p.mode != ModeWarn {
+		klog.Errorf("Failed to create container adjustment: %v", err)
+		return nil, fmt.Errorf("failed to create container adjustment: %w", err)
+	}
+	klog.Warningf("Skipping settings of container %s that cannot be applied through NRI: %v", container.GetName(), err)
+	return adjustment, nil
+}
+
+// stripSpecEnv removes the spec (or the references to it) from the container
+// environment once it has been consumed, to avoid exposing hook paths, env
+// values etc. to the workload. The adjustment is created if necessary.
+func (p *Plugin) stripSpecEnv(adjustment *api.ContainerAdjustment, container *api.Container) *api.ContainerAdjustment {
+	if p.keepSpecEnv {
+		return adjustment
+	}
+	keys := slices.Sorted(maps.Keys(getRefEnv(container)))
+	if getEnv(container, EnvKeyOCIRuntimeSpec) != "" {
+		keys = append(keys, EnvKeyOCIRuntimeSpec)
+	}
+	for _, key := range keys {
+		if adjustment == nil {
+			adjustment = &api.ContainerAdjustment{}
+		}
+		adjustment.RemoveEnv(key)
+	}
+	return adjustment
+}
+
+// getFailurePolicy returns the failure policy for container. Specs from
+// annotations and inline specs use the node's failure policy. Config files
+// may override it, a config that cannot be read or is not meant for the pod
+// uses the node's. If the configs of the container disagree, it fails closed.
+func (p *Plugin) getFailurePolicy(pod *api.PodSandbox, container *api.Container) configapi.FailurePolicy {
+	if p.allowInlineSpecs && getConfigAnnotation(pod, container) != "" {
+		return p.failurePolicy
+	}
+	var policies []configapi.FailurePolicy
+	for _, value := range getRefs(container) {
+		policy := p.failurePolicy
+		if ref, err := handoff.ParseRef(value); err == nil {
+			if config, err := p.readConfig(pod, ref); err == nil && config.FailurePolicy != "" {
+				policy = config.FailurePolicy
+			}
+		}
+		policies = append(policies, policy)
+	}
+	if len(policies) == 0 {
+		return p.failurePolicy
+	}
+	if slices.Contains(policies, configapi.FailurePolicyFailClosed) {
+		return configapi.FailurePolicyFailClosed
+	}
+	return configapi.FailurePolicyFailOpen
+}
+
+// prepareSpec validates spec and removals, checks spec against the node policy
+// and returns it in the form to apply on this host: with any seccomp profile
+// reference resolved and, on cgroup v1 hosts, unified parameters converted. In
+// warn mode a seccomp profile that cannot be loaded and unified parameters that
+// cannot be converted are dropped.
+func (p *Plugin) prepareSpec(spec *configapi.RuntimeSpec, removals *configapi.Removals) (*configapi.RuntimeSpec, error) {
+	if err := configapi.ValidateRuntimeSpec(spec, field.NewPath("spec")).ToAggregate(); err != nil {
+		return nil, fmt.Errorf("invalid OCI runtime spec: %w", err)
+	}
+	if err := configapi.ValidateRemovals(removals, spec, field.NewPath("remove")).ToAggregate(); err != nil {
+		return nil, fmt.Errorf("invalid removals: %w", err)
+	}
+	if err := p.policy.Check(spec); err != nil {
+		return nil, fmt.Errorf("OCI runtime spec denied by node policy: %w", err)
+	}
+
+	resolved, err := resolveSeccompProfile(spec, p.seccompRoot)
+	if err != nil {
+		if p.mode != ModeWarn {
+			return nil, err
+		}
+		klog.Warningf("Skipping seccomp profile that cannot be loaded on this host: %v", err)
+		resolved = spec.DeepCopy()
+		delete(resolved.Annotations, configapi.SeccompProfileAnnotation)
+	}
+	spec = resolved
+
+	if !p.cgroup2 {
+		converted, err := convertToCgroupV1(spec)
+		if err != nil {
+			if p.mode != ModeWarn {
+				return nil, err
+			}
+			klog.Warningf("Skipping resources that cannot be applied on this host: %v", err)
+		}
+		spec = converted
+	}
+	return spec, nil
+}
+
+// getRuntimeSpec returns the runtime spec to apply to container, or nil if
+// there is none, together with the settings to remove from it. Sources are checked in order of precedence:
+// 1. Container annotations (nri.runtime-spec.io/config), with allowInlineSpecs
+// 2. Pod annotations (nri.runtime-spec.io/config), with allowInlineSpecs
+// 3. Config files referenced by OCI_RUNTIME_SPEC_REF_* - set by DRA plugin via
+// CDI, one per claim and device, or recorded in the nri.runtime-spec.io/refs
+// annotation of a running container. All of them are merged into a single spec.
+// Each file must have been written for a claim reserved for pod.
+// 4. Inline OCI_RUNTIME_SPEC - set by earlier versions of the DRA plugin, with
+// allowInlineSpecs
+//
+// Each spec is reconciled with the container's current resources according to
+// the merge strategy of its config (see applyMergeStrategy). Specs without a
+// config use the default merge strategy. Removals can only be requested
+// through config files.
+func (p *Plugin) getRuntimeSpec(pod *api.PodSandbox, container *api.Container) (*configapi.RuntimeSpec, *configapi.Removals, error) {
+	current := container.GetLinux().GetResources()
+
+	configJSON := getConfigAnnotation(pod, container)
+	if configJSON != "" && !p.allowInlineSpecs {
+		p.inlineSpecIgnored(pod, container, "the "+AnnotationKeyConfig+" annotation")
+	} else if configJSON != "" {
+		spec, err := configapi.ParseRuntimeSpec([]byte(configJSON))
+		if err != nil {
+			return nil, nil, err
+		}
+		spec, err = applyMergeStrategy(configapi.DefaultMergeStrategy, AnnotationKeyConfig, spec, current)
+		return spec, nil, err
+	}
+
+	if refs := getRefs(container); len(refs) > 0 {
+		specs := make([]sourcedSpec, 0, len(refs))
+		var removals *configapi.Removals
+		for _, value := range refs {
+			ref, err := handoff.ParseRef(value)
+			if err != nil {
+				return nil, nil, err
+			}
+			config, err := p.readConfig(pod, ref)
+			if err != nil {
+				return nil, nil, err
+			}
+			if err := p.policy.CheckConfig(config); err != nil {
+				return nil, nil, fmt.Errorf("config file for %s denied by node policy: %w", ref, err)
+			}
+			spec, err := resolveIOLimits(config)
+			if err != nil {
+				if p.mode != ModeWarn {
+					return nil, nil, err
+				}
+				klog.Warningf("Skipping ioLimits of %s that cannot be applied on this host: %v", ref, err)
+				spec = config.Spec
+			}
+			spec, err = applyMergeStrategy(config.MergeStrategy, ref.String(), spec, current)
+			if err != nil {
+				return nil, nil, err
+			}
+			specs = append(specs, sourcedSpec{source: ref.String(), spec: spec})
+			removals = mergeRemovals(removals, config.Remove)
+		}
+		spec, err := mergeRuntimeSpecs(specs)
+		return spec, removals, err
+	}
+
+	configJSON = getEnv(container, EnvKeyOCIRuntimeSpec)
+	if configJSON != "" && !p.allowInlineSpecs {
+		p.inlineSpecIgnored(pod, container, EnvKeyOCIRuntimeSpec)
+	} else if configJSON != "" {
+		spec, err := configapi.ParseRuntimeSpec([]byte(configJSON))
+		if err != nil {
+			return nil, nil, err
+		}
+		spec, err = applyMergeStrategy(configapi.DefaultMergeStrategy, EnvKeyOCIRuntimeSpec, spec, current)
+		return spec, nil, err
+	}
+
+	return nil, nil, nil
+}
+
+// inlineSpecIgnored reports that the inline spec of container from source is
+// ignored. Earlier versions applied inline specs by default, so the first time
+// for each container this is logged as a warning and recorded as a pod event.
+func (p *Plugin) inlineSpecIgnored(pod *api.PodSandbox, container *api.Container, source string) {
+	if _, reported := p.inlineIgnored.LoadOrStore(container.GetId(), true); reported {
+		klog.V(2).Infof("Ignoring %s of container %s, inline specs are disabled", source, container.GetName())
+		return
+	}
+	klog.Warningf("Ignoring %s of container %s in pod %s/%s: inline specs are disabled, run the NRI plugin with -allow-inline-specs to apply them",
+		source, container.GetName(), pod.GetNamespace(), pod.GetName())
+	p.podEvent(pod, corev1.EventTypeWarning, "InlineSpecIgnored",
+		"Runtime spec in %s of container %s ignored, inline specs are disabled on this node", source, container.GetName())
+}
+
+// readConfig returns the config stored for ref, provided it was written for a
+// claim reserved for pod. References are passed through the environment, which
+// pod authors control, so this keeps a pod from using the config of a claim
+// that was admitted for another pod or namespace.
+func (p *Plugin) readConfig(pod *api.PodSandbox, ref handoff.Ref) (*configapi.RuntimeSpecEditConfig, error) {
+	f, err := handoff.Read(p.specDir, ref)
+	if err != nil {
+		return nil, err
+	}
+	if err := f.Owner.Check(pod.GetNamespace(), pod.GetUid()); err != nil {
+		return nil, fmt.Errorf("config file for %s cannot be applied to pod %s/%s: %w", ref, pod.GetNamespace(), pod.GetName(), err)
+	}
+	return f.Config, nil
+}
+
 // getConfigAnnotation retrieves the runtime-spec config annotation from pod or container
 func getConfigAnnotation(pod *api.PodSandbox, container *api.Container) string {
 	// First check container annotations
@@ -114,22 +472,52 @@ func getConfigAnnotation(pod *api.PodSandbox, container *api.Container) string {
 	return ""
 }
 
-// getConfigFromEnv retrieves the runtime-spec config from container environment variables
+// getEnv retrieves the value of the first environment variable named key
 // This is the mechanism used by the DRA plugin via CDI container edits
-func getConfigFromEnv(container *api.Container) string {
-	prefix := EnvKeyOCIRuntimeSpec + "="
+func getEnv(container *api.Container, key string) string {
+	prefix := key + "="
 	for _, env := range container.GetEnv() {
-		if len(env) > len(prefix) && env[:len(prefix)] == prefix {
-			return env[len(prefix):]
+		if value, ok := strings.CutPrefix(env, prefix); ok {
+			return value
 		}
 	}
 	return ""
 }
 
-// createAdjustment creates an NRI ContainerAdjustment from an OCI runtime spec
-func createAdjustment(ociSpec *spec.Spec) (*api.ContainerAdjustment, error) {
+// getRefs returns the config file references of container in sorted order,
+// so the merge result does not depend on the order in which the runtime
+// injected the CDI edits. They are taken from the environment or, once it has
+// been stripped, from the annotation recorded in CreateContainer.
+func getRefs(container *api.Container) []string {
+	if refEnv := getRefEnv(container); len(refEnv) > 0 {
+		return slices.Compact(slices.Sorted(maps.Values(refEnv)))
+	}
+	if value := container.GetAnnotations()[AnnotationKeyRefs]; value != "" {
+		return slices.Compact(slices.Sorted(slices.Values(strings.Split(value, ","))))
+	}
+	return nil
+}
+
+// getRefEnv returns the environment variables carrying config file references,
+// keyed by name.
+func getRefEnv(container *api.Container) map[string]string {
+	refs := make(map[string]string)
+	for _, env := range container.GetEnv() {
+		name, value, ok := strings.Cut(env, "=")
+		if ok && value != "" && handoff.IsEnvKey(name) {
+			refs[name] = value
+		}
+	}
+	return refs
+}
+
+// createAdjustment creates an NRI ContainerAdjustment from an OCI runtime spec.
+// Settings that cannot be translated are reported in the returned error, which
+// accompanies the adjustment for the remaining ones.
+func createAdjustment(ociSpec *configapi.RuntimeSpec) (*api.ContainerAdjustment, error) {
 	adjustment := &api.ContainerAdjustment{}
 	hasAdjustments := false
+	var errs []error
 
 	// TODO: i dont love having these manual implementations for merging, but
 	// the types aren't super compatible so we have to live with it for now.
@@ -139,82 +527,59 @@ func createAdjustment(ociSpec *spec.Spec) (*api.ContainerAdjustment, error) {
 		linuxAdj := &api.LinuxContainerAdjustment{}
 
 		if ociSpec.Linux.Resources != nil {
-			resources := &api.LinuxResources{}
-
-			// Apply unified cgroup v2 parameters
-			if len(ociSpec.Linux.Resources.Unified) > 0 {
-				resources.Unified = ociSpec.Linux.Resources.Unified
-				hasAdjustments = true
-				klog.V(2).Infof("Setting unified cgroup params: %v", ociSpec.Linux.Resources.Unified)
-			}
-
-			// Apply memory limits
-			if ociSpec.Linux.Resources.Memory != nil {
-				mem := ociSpec.Linux.Resources.Memory
-				resources.Memory = &api.LinuxMemory{}
-				if mem.Limit != nil {
-					resources.Memory.Limit = &api.OptionalInt64{Value: *mem.Limit}
-					hasAdjustments = true
-				}
-				if mem.Reservation != nil {
-					resources.Memory.Reservation = &api.OptionalInt64{Value: *mem.Reservation}
-					hasAdjustments = true
-				}
-				if mem.Swap != nil {
-					resources.Memory.Swap = &api.OptionalInt64{Value: *mem.Swap}
-					hasAdjustments = true
-				}
-				if mem.Swappiness != nil {
-					resources.Memory.Swappiness = &api.OptionalUInt64{Value: *mem.Swappiness}
-					hasAdjustments = true
-				}
-				if mem.DisableOOMKiller != nil {
-					resources.Memory.DisableOomKiller = &api.OptionalBool{Value: *mem.DisableOOMKiller}
-					hasAdjustments = true
-				}
-			}
-
-			// Apply CPU limits
-			if ociSpec.Linux.Resources.CPU != nil {
-				cpu := ociSpec.Linux.Resources.CPU
-				resources.Cpu = &api.LinuxCPU{}
-				if cpu.Shares != nil {
-					resources.Cpu.Shares = &api.OptionalUInt64{Value: *cpu.Shares}
-					hasAdjustments = true
-				}
-				if cpu.Quota != nil {
-					resources.Cpu.Quota = &api.OptionalInt64{Value: *cpu.Quota}
-					hasAdjustments = true
-				}
-				if cpu.Period != nil {
-					resources.Cpu.Period = &api.OptionalUInt64{Value: *cpu.Period}
-					hasAdjustments = true
-				}
-				if cpu.Cpus != "" {
-					resources.Cpu.Cpus = cpu.Cpus
-					hasAdjustments = true
-				}
-				if cpu.Mems != "" {
-					resources.Cpu.Mems = cpu.Mems
-					hasAdjustments = true
-				}
+			resources, ok, err := convertResources(ociSpec.Linux.Resources)
+			if err != nil {
+				errs = append(errs, err)
 			}
-
-			// Apply hugepage limits
-			if len(ociSpec.Linux.Resources.HugepageLimits) > 0 {
-				for _, hp := range ociSpec.Linux.Resources.HugepageLimits {
-					resources.HugepageLimits = append(resources.HugepageLimits, &api.HugepageLimit{
-						PageSize: hp.Pagesize,
-						Limit:    hp.Limit,
-					})
-				}
+			if ok {
 				hasAdjustments = true
 			}
-
 			linuxAdj.Resources = resources
 		}
 
 		adjustment.Linux = linuxAdj
+
+		// Apply the RDT class, which the runtime maps to a resctrl group
+		if ociSpec.Linux.IntelRdt != nil && ociSpec.Linux.IntelRdt.ClosID != "" {
+			adjustment.SetLinuxRDTClass(ociSpec.Linux.IntelRdt.ClosID)
+			hasAdjustments = true
+			klog.V(2).Infof("Setting RDT class to %s", ociSpec.Linux.IntelRdt.ClosID)
+		}
+	}
+
+	// Apply namespaces
+	if ociSpec.Linux != nil && len(ociSpec.Linux.Namespaces) > 0 {
+		for _, ns := range ociSpec.Linux.Namespaces {
+			adjustment.AddOrReplaceNamespace(&api.LinuxNamespace{
+				Type: ns.Type,
+				Path: ns.Path,
+			})
+		}
+		hasAdjustments = true
+		klog.V(2).Infof("Setting namespaces: %v", ociSpec.Linux.Namespaces)
+	}
+
+	// Apply the seccomp profile
+	if ociSpec.Linux != nil && ociSpec.Linux.Seccomp != nil {
+		adjustment.SetLinuxSeccompPolicy(convertSeccomp(ociSpec.Linux.Seccomp))
+		hasAdjustments = true
+		klog.V(2).Infof("Setting seccomp profile with default action %s and %d syscall rules", ociSpec.Linux.Seccomp.DefaultAction, len(ociSpec.Linux.Seccomp.Syscalls))
+	}
+
+	// Apply the blockio class
+	if class := ociSpec.Annotations[configapi.BlockIOClassAnnotation]; class != "" {
+		adjustment.SetLinuxBlockIOClass(class)
+		hasAdjustments = true
+		klog.V(2).Infof("Setting blockio class to %s", class)
+	}
+
+	// Apply sysctls
+	if ociSpec.Linux != nil && len(ociSpec.Linux.Sysctl) > 0 {
+		for _, key := range slices.Sorted(maps.Keys(ociSpec.Linux.Sysctl)) {
+			adjustment.SetLinuxSysctl(key, ociSpec.Linux.Sysctl[key])
+		}
+		hasAdjustments = true
+		klog.V(2).Infof("Setting sysctls: %v", ociSpec.Linux.Sysctl)
 	}
 
 	// Apply environment variables
@@ -230,6 +595,29 @@ func createAdjustment(ociSpec *spec.Spec) (*api.ContainerAdjustment, error) {
 		klog.V(2).Infof("Adding %d environment variables", len(ociSpec.Process.Env))
 	}
 
+	// Refuse process fields NRI cannot express instead of silently dropping them
+	if ociSpec.Process != nil {
+		if unsupported := configapi.UnsupportedProcessFields(ociSpec.Process); len(unsupported) > 0 {
+			errs = append(errs, fmt.Errorf("process fields %v cannot be applied through NRI", unsupported))
+		}
+	}
+
+	// Apply OOM score adjustment
+	if ociSpec.Process != nil && ociSpec.Process.OOMScoreAdj != nil {
+		adjustment.SetLinuxOomScoreAdj(ociSpec.Process.OOMScoreAdj)
+		hasAdjustments = true
+		klog.V(2).Infof("Setting OOM score adjustment to %d", *ociSpec.Process.OOMScoreAdj)
+	}
+
+	// Apply rlimits
+	if ociSpec.Process != nil && len(ociSpec.Process.Rlimits) > 0 {
+		for _, r := range ociSpec.Process.Rlimits {
+			adjustment.AddRlimit(r.Type, r.Hard, r.Soft)
+		}
+		hasAdjustments = true
+		klog.V(2).Infof("Adding %d rlimits", len(ociSpec.Process.Rlimits))
+	}
+
 	// Apply mounts
 	if len(ociSpec.Mounts) > 0 {
 		for _, m := range ociSpec.Mounts {
@@ -264,7 +652,7 @@ func createAdjustment(ociSpec *spec.Spec) (*api.ContainerAdjustment, error) {
 				Minor: d.Minor,
 			}
 			if d.FileMode != nil {
-				dev.FileMode = &api.OptionalFileMode{Value: uint32(*d.FileMode)}
+				dev.FileMode = &api.OptionalFileMode{Value: *d.FileMode}
 			}
 			if d.UID != nil {
 				dev.Uid = &api.OptionalUInt32{Value: *d.UID}
@@ -279,14 +667,155 @@ func createAdjustment(ociSpec *spec.Spec) (*api.ContainerAdjustment, error) {
 	}
 
 	if !hasAdjustments {
-		return nil, nil
+		adjustment = nil
 	}
 
-	return adjustment, nil
+	return adjustment, errors.Join(errs...)
+}
+
+// convertResources converts OCI resources to NRI resources. It reports whether
+// any resource is set.
+func convertResources(res *configapi.LinuxResources) (*api.LinuxResources, bool, error) {
+	resources := &api.LinuxResources{}
+	hasResources := false
+	var errs []error
+
+	// Refuse resource fields NRI cannot express instead of silently dropping them
+	if unsupported := configapi.UnsupportedResourceFields(res); len(unsupported) > 0 {
+		errs = append(errs, fmt.Errorf("resource fields %v cannot be applied through NRI", unsupported))
+	}
+
+	// Apply unified cgroup v2 parameters, including blockIO and cpu burst and
+	// idle translated to unified keys
+	unified, err := res.EffectiveUnified()
+	if err != nil {
+		errs = append(errs, fmt.Errorf("failed to translate resources to unified parameters: %w", err))
+	}
+	if len(unified) > 0 {
+		resources.Unified = unified
+		hasResources = true
+		klog.V(2).Infof("Setting unified cgroup params: %v", unified)
+	}
+
+	// Apply memory limits
+	if res.Memory != nil {
+		mem := res.Memory
+		resources.Memory = &api.LinuxMemory{}
+		if mem.Limit != nil {
+			resources.Memory.Limit = &api.OptionalInt64{Value: *mem.Limit}
+			hasResources = true
+		}
+		if mem.Reservation != nil {
+			resources.Memory.Reservation = &api.OptionalInt64{Value: *mem.Reservation}
+			hasResources = true
+		}
+		if mem.Swap != nil {
+			resources.Memory.Swap = &api.OptionalInt64{Value: *mem.Swap}
+			hasResources = true
+		}
+		if mem.Kernel != nil {
+			resources.Memory.Kernel = &api.OptionalInt64{Value: *mem.Kernel}
+			hasResources = true
+		}
+		if mem.KernelTCP != nil {
+			resources.Memory.KernelTcp = &api.OptionalInt64{Value: *mem.KernelTCP}
+			hasResources = true
+		}
+		if mem.Swappiness != nil {
+			resources.Memory.Swappiness = &api.OptionalUInt64{Value: *mem.Swappiness}
+			hasResources = true
+		}
+		if mem.DisableOOMKiller != nil {
+			resources.Memory.DisableOomKiller = &api.OptionalBool{Value: *mem.DisableOOMKiller}
+			hasResources = true
+		}
+		if mem.UseHierarchy != nil {
+			resources.Memory.UseHierarchy = &api.OptionalBool{Value: *mem.UseHierarchy}
+			hasResources = true
+		}
+	}
+
+	// Apply CPU limits
+	if res.CPU != nil {
+		cpu := res.CPU
+		resources.Cpu = &api.LinuxCPU{}
+		if cpu.Shares != nil {
+			resources.Cpu.Shares = &api.OptionalUInt64{Value: *cpu.Shares}
+			hasResources = true
+		}
+		if cpu.Quota != nil {
+			resources.Cpu.Quota = &api.OptionalInt64{Value: *cpu.Quota}
+			hasResources = true
+		}
+		if cpu.Period != nil {
+			resources.Cpu.Period = &api.OptionalUInt64{Value: *cpu.Period}
+			hasResources = true
+		}
+		if cpu.RealtimeRuntime != nil {
+			resources.Cpu.RealtimeRuntime = &api.OptionalInt64{Value: *cpu.RealtimeRuntime}
+			hasResources = true
+		}
+		if cpu.RealtimePeriod != nil {
+			resources.Cpu.RealtimePeriod = &api.OptionalUInt64{Value: *cpu.RealtimePeriod}
+			hasResources = true
+		}
+		if cpu.Cpus != "" {
+			resources.Cpu.Cpus = cpu.Cpus
+			hasResources = true
+		}
+		if cpu.Mems != "" {
+			resources.Cpu.Mems = cpu.Mems
+			hasResources = true
+		}
+	}
+
+	// Apply pids limit
+	if res.Pids != nil {
+		resources.Pids = &api.LinuxPids{Limit: res.Pids.Limit}
+		hasResources = true
+		klog.V(2).Infof("Setting pids limit to %d", res.Pids.Limit)
+	}
+
+	// Apply hugepage limits
+	if len(res.HugepageLimits) > 0 {
+		for _, hp := range res.HugepageLimits {
+			resources.HugepageLimits = append(resources.HugepageLimits, &api.HugepageLimit{
+				PageSize: hp.Pagesize,
+				Limit:    hp.Limit,
+			})
+		}
+		hasResources = true
+	}
+
+	return resources, hasResources, errors.Join(errs...)
+}
+
+// addRemovals records the removals in adjustment, creating it if necessary.
+func addRemovals(adjustment *api.ContainerAdjustment, remove *configapi.Removals) *api.ContainerAdjustment {
+	if remove == nil {
+		return adjustment
+	}
+	if adjustment == nil {
+		adjustment = &api.ContainerAdjustment{}
+	}
+	for _, name := range remove.Env {
+		adjustment.RemoveEnv(name)
+	}
+	for _, destination := range remove.Mounts {
+		adjustment.RemoveMount(destination)
+	}
+	for _, path := range remove.Devices {
+		adjustment.RemoveDevice(path)
+	}
+	for _, key := range remove.Annotations {
+		adjustment.RemoveAnnotation(key)
+	}
+	klog.V(2).Infof("Removing env=%v, mounts=%v, devices=%v, annotations=%v", remove.Env, remove.Mounts, remove.Devices, remove.Annotations)
+	return adjustment
 }
 
 // convertHooks converts OCI hooks to NRI hooks
-func convertHooks(ociHooks *spec.Hooks) *api.Hooks {
+func convertHooks(ociHooks *configapi.Hooks) *api.Hooks {
 	if ociHooks == nil {
 		return nil
 	}
@@ -344,7 +873,7 @@ func convertHooks(ociHooks *spec.Hooks) *api.Hooks {
 }
 
 // convertHook converts an OCI hook to an NRI hook
-func convertHook(h spec.Hook) *api.Hook {
+func convertHook(h configapi.Hook) *api.Hook {
 	hook := &api.Hook{
 		Path: h.Path,
 		Args: h.Args,
@@ -0,0 +1,452 @@
+package main
+
+import (
+	"context"
+	"maps"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/containerd/nri/pkg/api"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/utils/ptr"
+
+	configapi "runtime-spec-dra-driver/api/v1alpha1"
+	"runtime-spec-dra-driver/pkg/handoff"
+	"runtime-spec-dra-driver/pkg/policy"
+)
+
+// newTestContainer writes config to a handoff file in dir, as the kubelet
+// plugin does when preparing a claim, and returns a pod the claim is reserved
+// for with a container referencing the file.
+func newTestContainer(t *testing.T, dir string, config *configapi.RuntimeSpecEditConfig) (*api.PodSandbox, *api.Container) {
+	t.Helper()
+	pod := &api.PodSandbox{Id: "sandbox-id", Name: "test", Namespace: "team-a", Uid: "pod-uid"}
+	ref := handoff.Ref{ClaimUID: "claim-uid", Device: "runtime-spec-0"}
+	owner := handoff.Owner{Namespace: pod.Namespace, PodUIDs: []string{pod.Uid}}
+	if _, err := handoff.Write(dir, ref, &handoff.File{Owner: owner, Config: config}); err != nil {
+		t.Fatalf("handoff.Write: %v", err)
+	}
+	container := &api.Container{
+		Id:           "container-id",
+		PodSandboxId: pod.Id,
+		Name:         "ctr0",
+		Env:          []string{"PATH=/usr/bin", ref.EnvKey() + "=" + ref.String()},
+	}
+	return pod, container
+}
+
+func TestCreateAdjustmentResources(t *testing.T) {
+	memoryValue := func(get func(*api.LinuxMemory) any) func(*api.LinuxResources) any {
+		return func(r *api.LinuxResources) any { return get(r.GetMemory()) }
+	}
+	cpuValue := func(get func(*api.LinuxCPU) any) func(*api.LinuxResources) any {
+		return func(r *api.LinuxResources) any { return get(r.GetCpu()) }
+	}
+	unified := func(r *api.LinuxResources) any { return r.GetUnified() }
+
+	tests := []struct {
+		name      string
+		resources *configapi.LinuxResources
+		// get extracts the adjusted value compared with want.
+		get  func(*api.LinuxResources) any
+		want any
+		// wantInvalid is set for specs ValidateRuntimeSpec rejects.
+		wantInvalid bool
+		// wantErr is a substring of the error createAdjustment returns for
+		// settings it cannot translate.
+		wantErr string
+	}{
+		{
+			name:      "memory limit",
+			resources: &configapi.LinuxResources{Memory: &configapi.LinuxMemory{Limit: ptr.To[int64](1 << 30)}},
+			get:       memoryValue(func(m *api.LinuxMemory) any { return m.GetLimit().GetValue() }),
+			want:      int64(1 << 30),
+		},
+		{
+			name:      "memory reservation",
+			resources: &configapi.LinuxResources{Memory: &configapi.LinuxMemory{Reservation: ptr.To[int64](1 << 29)}},
+			get:       memoryValue(func(m *api.LinuxMemory) any { return m.GetReservation().GetValue() }),
+			want:      int64(1 << 29),
+		},
+		{
+			name:      "memory swap",
+			resources: &configapi.LinuxResources{Memory: &configapi.LinuxMemory{Swap: ptr.To[int64](1 << 31)}},
+			get:       memoryValue(func(m *api.LinuxMemory) any { return m.GetSwap().GetValue() }),
+			want:      int64(1 << 31),
+		},
+		{
+			name:      "memory kernel",
+			resources: &configapi.LinuxResources{Memory: &configapi.LinuxMemory{Kernel: ptr.To[int64](1 << 26)}},
+			get:       memoryValue(func(m *api.LinuxMemory) any { return m.GetKernel().GetValue() }),
+			want:      int64(1 << 26),
+		},
+		{
+			name:      "memory kernelTCP",
+			resources: &configapi.LinuxResources{Memory: &configapi.LinuxMemory{KernelTCP: ptr.To[int64](1 << 25)}},
+			get:       memoryValue(func(m *api.LinuxMemory) any { return m.GetKernelTcp().GetValue() }),
+			want:      int64(1 << 25),
+		},
+		{
+			name:      "memory swappiness",
+			resources: &configapi.LinuxResources{Memory: &configapi.LinuxMemory{Swappiness: ptr.To[uint64](10)}},
+			get:       memoryValue(func(m *api.LinuxMemory) any { return m.GetSwappiness().GetValue() }),
+			want:      uint64(10),
+		},
+		{
+			name:      "memory disableOOMKiller",
+			resources: &configapi.LinuxResources{Memory: &configapi.LinuxMemory{DisableOOMKiller: ptr.To(true)}},
+			get:       memoryValue(func(m *api.LinuxMemory) any { return m.GetDisableOomKiller().GetValue() }),
+			want:      true,
+		},
+		{
+			name:      "memory useHierarchy",
+			resources: &configapi.LinuxResources{Memory: &configapi.LinuxMemory{UseHierarchy: ptr.To(true)}},
+			get:       memoryValue(func(m *api.LinuxMemory) any { return m.GetUseHierarchy().GetValue() }),
+			want:      true,
+		},
+		{
+			name:        "memory checkBeforeUpdate",
+			resources:   &configapi.LinuxResources{Memory: &configapi.LinuxMemory{CheckBeforeUpdate: ptr.To(true)}},
+			wantInvalid: true,
+			wantErr:     "memory.checkBeforeUpdate",
+		},
+		{
+			name:      "cpu shares",
+			resources: &configapi.LinuxResources{CPU: &configapi.LinuxCPU{Shares: ptr.To[uint64](2048)}},
+			get:       cpuValue(func(c *api.LinuxCPU) any { return c.GetShares().GetValue() }),
+			want:      uint64(2048),
+		},
+		{
+			name:      "cpu quota",
+			resources: &configapi.LinuxResources{CPU: &configapi.LinuxCPU{Quota: ptr.To[int64](50000)}},
+			get:       cpuValue(func(c *api.LinuxCPU) any { return c.GetQuota().GetValue() }),
+			want:      int64(50000),
+		},
+		{
+			name:      "cpu period",
+			resources: &configapi.LinuxResources{CPU: &configapi.LinuxCPU{Period: ptr.To[uint64](200000)}},
+			get:       cpuValue(func(c *api.LinuxCPU) any { return c.GetPeriod().GetValue() }),
+			want:      uint64(200000),
+		},
+		{
+			name:      "cpu realtimeRuntime",
+			resources: &configapi.LinuxResources{CPU: &configapi.LinuxCPU{RealtimeRuntime: ptr.To[int64](950000)}},
+			get:       cpuValue(func(c *api.LinuxCPU) any { return c.GetRealtimeRuntime().GetValue() }),
+			want:      int64(950000),
+		},
+		{
+			name:      "cpu realtimePeriod",
+			resources: &configapi.LinuxResources{CPU: &configapi.LinuxCPU{RealtimePeriod: ptr.To[uint64](1000000)}},
+			get:       cpuValue(func(c *api.LinuxCPU) any { return c.GetRealtimePeriod().GetValue() }),
+			want:      uint64(1000000),
+		},
+		{
+			name:      "cpu cpus",
+			resources: &configapi.LinuxResources{CPU: &configapi.LinuxCPU{Cpus: "0-3"}},
+			get:       cpuValue(func(c *api.LinuxCPU) any { return c.GetCpus() }),
+			want:      "0-3",
+		},
+		{
+			name:      "cpu mems",
+			resources: &configapi.LinuxResources{CPU: &configapi.LinuxCPU{Mems: "0"}},
+			get:       cpuValue(func(c *api.LinuxCPU) any { return c.GetMems() }),
+			want:      "0",
+		},
+		{
+			name:      "cpu burst",
+			resources: &configapi.LinuxResources{CPU: &configapi.LinuxCPU{Burst: ptr.To[uint64](50000)}},
+			get:       unified,
+			want:      map[string]string{"cpu.max.burst": "50000"},
+		},
+		{
+			name:      "cpu idle",
+			resources: &configapi.LinuxResources{CPU: &configapi.LinuxCPU{Idle: ptr.To[int64](1)}},
+			get:       unified,
+			want:      map[string]string{"cpu.idle": "1"},
+		},
+		{
+			name:      "cpu idle disabled",
+			resources: &configapi.LinuxResources{CPU: &configapi.LinuxCPU{Idle: ptr.To[int64](0)}},
+			get:       unified,
+			want:      map[string]string{"cpu.idle": "0"},
+		},
+		{
+			name: "cpu burst and idle merged with unified",
+			resources: &configapi.LinuxResources{
+				CPU:     &configapi.LinuxCPU{Burst: ptr.To[uint64](50000), Idle: ptr.To[int64](1)},
+				Unified: map[string]string{"cpu.max.burst": "50000", "memory.high": "1048576"},
+			},
+			get:  unified,
+			want: map[string]string{"cpu.max.burst": "50000", "cpu.idle": "1", "memory.high": "1048576"},
+		},
+		{
+			name:        "cpu idle out of range",
+			resources:   &configapi.LinuxResources{CPU: &configapi.LinuxCPU{Idle: ptr.To[int64](2)}},
+			wantInvalid: true,
+		},
+		{
+			name:        "negative cpu idle",
+			resources:   &configapi.LinuxResources{CPU: &configapi.LinuxCPU{Idle: ptr.To[int64](-1)}},
+			wantInvalid: true,
+		},
+		{
+			name: "cpu burst conflicts with unified",
+			resources: &configapi.LinuxResources{
+				CPU:     &configapi.LinuxCPU{Burst: ptr.To[uint64](50000)},
+				Unified: map[string]string{"cpu.max.burst": "10000"},
+			},
+			wantInvalid: true,
+			wantErr:     "cpu.max.burst",
+		},
+		{
+			name: "cpu idle conflicts with unified",
+			resources: &configapi.LinuxResources{
+				CPU:     &configapi.LinuxCPU{Idle: ptr.To[int64](1)},
+				Unified: map[string]string{"cpu.idle": "0"},
+			},
+			wantInvalid: true,
+			wantErr:     "cpu.idle",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			spec := &configapi.RuntimeSpec{Linux: &configapi.Linux{Resources: tt.resources}}
+
+			errs := configapi.ValidateRuntimeSpec(spec, field.NewPath("spec"))
+			if invalid := len(errs) > 0; invalid != tt.wantInvalid {
+				t.Fatalf("ValidateRuntimeSpec() = %v, want invalid %v", errs, tt.wantInvalid)
+			}
+
+			adjustment, err := createAdjustment(spec)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("createAdjustment() error = %v, want error containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("createAdjustment() error = %v", err)
+			}
+			if tt.wantInvalid {
+				return
+			}
+			if got := tt.get(adjustment.GetLinux().GetResources()); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("adjusted value = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCreateContainerClasses(t *testing.T) {
+	tests := []struct {
+		name             string
+		spec             *configapi.RuntimeSpec
+		wantRdtClass     string
+		wantBlockioClass string
+	}{
+		{
+			name: "rdt class",
+			spec: &configapi.RuntimeSpec{
+				Linux: &configapi.Linux{IntelRdt: &configapi.LinuxIntelRdt{ClosID: "gold"}},
+			},
+			wantRdtClass: "gold",
+		},
+		{
+			name: "blockio class",
+			spec: &configapi.RuntimeSpec{
+				Annotations: map[string]string{configapi.BlockIOClassAnnotation: "throttled"},
+			},
+			wantBlockioClass: "throttled",
+		},
+		{
+			name: "both classes",
+			spec: &configapi.RuntimeSpec{
+				Annotations: map[string]string{configapi.BlockIOClassAnnotation: "throttled"},
+				Linux:       &configapi.Linux{IntelRdt: &configapi.LinuxIntelRdt{ClosID: "gold"}},
+			},
+			wantRdtClass:     "gold",
+			wantBlockioClass: "throttled",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := &Plugin{specDir: t.TempDir(), cgroup2: true, mode: ModeEnforce, failurePolicy: configapi.FailurePolicyFailClosed}
+			pod, container := newTestContainer(t, p.specDir, &configapi.RuntimeSpecEditConfig{Spec: tt.spec})
+
+			adjustment, _, err := p.CreateContainer(context.Background(), pod, container)
+			if err != nil {
+				t.Fatalf("CreateContainer: %v", err)
+			}
+			resources := adjustment.GetLinux().GetResources()
+			if got := resources.GetRdtClass().GetValue(); got != tt.wantRdtClass {
+				t.Errorf("RdtClass = %q, want %q", got, tt.wantRdtClass)
+			}
+			if got := resources.GetBlockioClass().GetValue(); got != tt.wantBlockioClass {
+				t.Errorf("BlockioClass = %q, want %q", got, tt.wantBlockioClass)
+			}
+		})
+	}
+}
+
+func TestCreateContainerWarnMode(t *testing.T) {
+	memoryHigh := map[string]string{"memory.high": "1048576"}
+	tests := []struct {
+		name    string
+		config  *configapi.RuntimeSpecEditConfig
+		cgroup1 bool
+		// wantWarnErr is set for errors warn mode does not tolerate.
+		wantWarnErr bool
+		// wantUnified is what warn mode applies.
+		wantUnified map[string]string
+		check       func(t *testing.T, adjustment *api.ContainerAdjustment)
+	}{
+		{
+			name: "ioLimits device missing",
+			config: &configapi.RuntimeSpecEditConfig{
+				IOLimits: []configapi.IOLimit{{Device: "/dev/runtime-spec-test-missing", Rbps: ptr.To[uint64](1048576)}},
+				Spec:     &configapi.RuntimeSpec{Linux: &configapi.Linux{Resources: &configapi.LinuxResources{Unified: memoryHigh}}},
+			},
+			wantUnified: memoryHigh,
+		},
+		{
+			name: "seccomp profile missing",
+			config: &configapi.RuntimeSpecEditConfig{
+				Spec: &configapi.RuntimeSpec{
+					Annotations: map[string]string{configapi.SeccompProfileAnnotation: "missing.json"},
+					Linux:       &configapi.Linux{Resources: &configapi.LinuxResources{Unified: memoryHigh}},
+				},
+			},
+			wantUnified: memoryHigh,
+			check: func(t *testing.T, adjustment *api.ContainerAdjustment) {
+				if seccomp := adjustment.GetLinux().GetSeccompPolicy(); seccomp != nil {
+					t.Errorf("seccomp policy = %v, want none", seccomp)
+				}
+			},
+		},
+		{
+			name: "unified key without cgroup v1 equivalent",
+			config: &configapi.RuntimeSpecEditConfig{
+				Spec: &configapi.RuntimeSpec{Linux: &configapi.Linux{Resources: &configapi.LinuxResources{
+					Unified: map[string]string{"memory.high": "1048576", "memory.max": "2097152"},
+				}}},
+			},
+			cgroup1: true,
+			check: func(t *testing.T, adjustment *api.ContainerAdjustment) {
+				if got := adjustment.GetLinux().GetResources().GetMemory().GetLimit().GetValue(); got != 2097152 {
+					t.Errorf("memory limit = %d, want 2097152", got)
+				}
+			},
+		},
+		{
+			name: "denied by policy",
+			config: &configapi.RuntimeSpecEditConfig{
+				Spec: &configapi.RuntimeSpec{Linux: &configapi.Linux{
+					Namespaces: []configapi.LinuxNamespace{{Type: "network", Path: "/var/run/netns/test"}},
+				}},
+			},
+			wantWarnErr: true,
+		},
+	}
+	for _, tt := range tests {
+		for _, mode := range []Mode{ModeEnforce, ModeWarn} {
+			t.Run(tt.name+"/"+string(mode), func(t *testing.T) {
+				p := &Plugin{
+					policy:        &policy.Policy{RuntimeSpecPolicyRules: configapi.RuntimeSpecPolicyRules{AllowedSeccompProfiles: []string{"*"}}},
+					specDir:       t.TempDir(),
+					seccompRoot:   t.TempDir(),
+					cgroup2:       !tt.cgroup1,
+					mode:          mode,
+					failurePolicy: configapi.FailurePolicyFailClosed,
+				}
+				pod, container := newTestContainer(t, p.specDir, tt.config)
+
+				adjustment, _, err := p.CreateContainer(context.Background(), pod, container)
+				if wantErr := mode == ModeEnforce || tt.wantWarnErr; (err != nil) != wantErr {
+					t.Fatalf("CreateContainer error = %v, want error %v", err, wantErr)
+				}
+				if err != nil {
+					return
+				}
+				if got := adjustment.GetLinux().GetResources().GetUnified(); !maps.Equal(got, tt.wantUnified) {
+					t.Errorf("unified = %v, want %v", got, tt.wantUnified)
+				}
+				if tt.check != nil {
+					tt.check(t, adjustment)
+				}
+			})
+		}
+	}
+}
+
+func TestInlineSpecIgnoredOnce(t *testing.T) {
+	recorder := record.NewFakeRecorder(10)
+	p := &Plugin{specDir: t.TempDir(), cgroup2: true, mode: ModeEnforce, failurePolicy: configapi.FailurePolicyFailClosed, recorder: recorder}
+	pod := &api.PodSandbox{Id: "sandbox-id", Name: "test", Namespace: "team-a", Uid: "pod-uid"}
+	container := &api.Container{
+		Id:           "container-id",
+		PodSandboxId: pod.Id,
+		Name:         "ctr0",
+		Annotations:  map[string]string{AnnotationKeyConfig: `{"linux":{"resources":{"unified":{"memory.high":"1048576"}}}}`},
+		Env:          []string{EnvKeyOCIRuntimeSpec + `={"linux":{"resources":{"unified":{"memory.high":"1048576"}}}}`},
+	}
+
+	for range 2 {
+		adjustment, _, err := p.CreateContainer(context.Background(), pod, container)
+		if err != nil {
+			t.Fatalf("CreateContainer: %v", err)
+		}
+		if unified := adjustment.GetLinux().GetResources().GetUnified(); len(unified) > 0 {
+			t.Errorf("unified = %v, want inline specs ignored", unified)
+		}
+	}
+	if got := len(recorder.Events); got != 1 {
+		t.Errorf("recorded %d events, want 1", got)
+	}
+}
+
+func TestTranslateSpecWarnMode(t *testing.T) {
+	// Validation rejects settings createAdjustment cannot translate, so these
+	// specs only reach it if the two disagree.
+	tests := []struct {
+		name      string
+		resources *configapi.LinuxResources
+	}{
+		{
+			name: "unsupported memory field",
+			resources: &configapi.LinuxResources{Memory: &configapi.LinuxMemory{
+				Limit:             ptr.To[int64](1 << 30),
+				CheckBeforeUpdate: ptr.To(true),
+			}},
+		},
+		{
+			name: "cpu burst conflicts with unified",
+			resources: &configapi.LinuxResources{
+				Memory:  &configapi.LinuxMemory{Limit: ptr.To[int64](1 << 30)},
+				CPU:     &configapi.LinuxCPU{Burst: ptr.To[uint64](50000)},
+				Unified: map[string]string{"cpu.max.burst": "10000"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		for _, mode := range []Mode{ModeEnforce, ModeWarn} {
+			t.Run(tt.name+"/"+string(mode), func(t *testing.T) {
+				p := &Plugin{mode: mode}
+				spec := &configapi.RuntimeSpec{Linux: &configapi.Linux{Resources: tt.resources}}
+
+				adjustment, err := p.translateSpec(&api.Container{Name: "ctr0"}, spec)
+				if wantErr := mode == ModeEnforce; (err != nil) != wantErr {
+					t.Fatalf("translateSpec() error = %v, want error %v", err, wantErr)
+				}
+				if err != nil {
+					return
+				}
+				// Warn mode keeps the settings that could be translated.
+				if got := adjustment.GetLinux().GetResources().GetMemory().GetLimit().GetValue(); got != 1<<30 {
+					t.Errorf("memory limit = %d, want %d", got, 1<<30)
+				}
+			})
+		}
+	}
+}
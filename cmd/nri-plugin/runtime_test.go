@@ -0,0 +1,224 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/containerd/nri/pkg/adaptation"
+	"github.com/containerd/nri/pkg/api"
+	"github.com/containerd/nri/pkg/stub"
+	"k8s.io/utils/ptr"
+
+	configapi "runtime-spec-dra-driver/api/v1alpha1"
+	"runtime-spec-dra-driver/pkg/handoff"
+)
+
+// fakeRuntime is the runtime side of NRI, serving the plugin socket the way
+// containerd does, with a fixed set of pods and containers to synchronize.
+type fakeRuntime struct {
+	*adaptation.Adaptation
+	socketPath string
+	pods       []*api.PodSandbox
+	containers []*api.Container
+
+	mu sync.Mutex
+	// syncUpdates are the updates plugins returned from Synchronize.
+	syncUpdates []*api.ContainerUpdate
+	// updates are the updates plugins requested through UpdateContainers.
+	updates []*api.ContainerUpdate
+}
+
+func newFakeRuntime(t *testing.T, pods []*api.PodSandbox, containers []*api.Container) *fakeRuntime {
+	t.Helper()
+	// Unix socket paths are limited to 108 bytes, which t.TempDir can exceed.
+	dir, err := os.MkdirTemp("", "nri")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+
+	r := &fakeRuntime{socketPath: filepath.Join(dir, "nri.sock"), pods: pods, containers: containers}
+	r.Adaptation, err = adaptation.New("fake-runtime", "v0.0.0", r.synchronize, r.updateContainers,
+		adaptation.WithPluginPath(filepath.Join(dir, "plugins")),
+		adaptation.WithPluginConfigPath(filepath.Join(dir, "conf.d")),
+		adaptation.WithSocketPath(r.socketPath),
+	)
+	if err != nil {
+		t.Fatalf("adaptation.New: %v", err)
+	}
+	if err := r.Start(); err != nil {
+		t.Fatalf("start runtime: %v", err)
+	}
+	t.Cleanup(r.Stop)
+	return r
+}
+
+func (r *fakeRuntime) synchronize(ctx context.Context, cb adaptation.SyncCB) error {
+	updates, err := cb(ctx, r.pods, r.containers)
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.syncUpdates = append(r.syncUpdates, updates...)
+	return err
+}
+
+func (r *fakeRuntime) updateContainers(_ context.Context, updates []*api.ContainerUpdate) ([]*api.ContainerUpdate, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.updates = append(r.updates, updates...)
+	return nil, nil
+}
+
+// startPlugin registers p with the runtime through an NRI stub.
+func startPlugin(t *testing.T, r *fakeRuntime, p *Plugin) {
+	t.Helper()
+	var err error
+	p.stub, err = stub.New(p, stub.WithPluginName(PluginName), stub.WithPluginIdx("10"), stub.WithSocketPath(r.socketPath))
+	if err != nil {
+		t.Fatalf("stub.New: %v", err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	if err := p.stub.Start(ctx); err != nil {
+		cancel()
+		t.Fatalf("start plugin: %v", err)
+	}
+	t.Cleanup(func() {
+		p.stub.Stop()
+		cancel()
+	})
+}
+
+// createContainer sends a CreateContainer request for container. The runtime
+// only relays requests to the plugin once it is synchronized, which finishes
+// after the stub has started, so the request is retried until it reaches the
+// plugin.
+func createContainer(t *testing.T, r *fakeRuntime, p *Plugin, pod *api.PodSandbox, container *api.Container) *api.ContainerAdjustment {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		response, err := r.CreateContainer(context.Background(), &api.CreateContainerRequest{Pod: pod, Container: container})
+		if err != nil {
+			t.Fatalf("CreateContainer: %v", err)
+		}
+		p.index.Lock()
+		_, pending := p.index.pending[container.GetId()]
+		p.index.Unlock()
+		if pending {
+			return response.GetAdjust()
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("plugin did not receive CreateContainer")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+// writeMemoryLimit writes a config limiting memory to limit for ref, reserved
+// for pod.
+func writeMemoryLimit(t *testing.T, dir string, ref handoff.Ref, pod *api.PodSandbox, limit int64) {
+	t.Helper()
+	config := &configapi.RuntimeSpecEditConfig{Spec: &configapi.RuntimeSpec{
+		Linux: &configapi.Linux{Resources: &configapi.LinuxResources{Memory: &configapi.LinuxMemory{Limit: ptr.To(limit)}}},
+	}}
+	owner := handoff.Owner{Namespace: pod.Namespace, PodUIDs: []string{pod.Uid}}
+	if _, err := handoff.Write(dir, ref, &handoff.File{Owner: owner, Config: config}); err != nil {
+		t.Fatalf("handoff.Write: %v", err)
+	}
+}
+
+func TestRuntimeLifecycle(t *testing.T) {
+	specDir := t.TempDir()
+	pod := &api.PodSandbox{Id: "sandbox-id", Name: "test", Namespace: "team-a", Uid: "pod-uid"}
+
+	existingRef := handoff.Ref{ClaimUID: "existing-claim", Device: "runtime-spec-0"}
+	writeMemoryLimit(t, specDir, existingRef, pod, 1<<30)
+	existing := &api.Container{
+		Id:           "existing-id",
+		PodSandboxId: pod.Id,
+		Name:         "existing",
+		State:        api.ContainerState_CONTAINER_RUNNING,
+		Env:          []string{existingRef.EnvKey() + "=" + existingRef.String()},
+	}
+
+	newRef := handoff.Ref{ClaimUID: "new-claim", Device: "runtime-spec-0"}
+	writeMemoryLimit(t, specDir, newRef, pod, 1<<29)
+	created := &api.Container{
+		Id:           "created-id",
+		PodSandboxId: pod.Id,
+		Name:         "created",
+		Env:          []string{newRef.EnvKey() + "=" + newRef.String()},
+	}
+
+	r := newFakeRuntime(t, []*api.PodSandbox{pod}, []*api.Container{existing})
+	p := &Plugin{
+		specDir:       specDir,
+		cgroupRoot:    "/sys/fs/cgroup",
+		mode:          ModeEnforce,
+		failurePolicy: configapi.FailurePolicyFailClosed,
+	}
+	startPlugin(t, r, p)
+
+	// The plugin is synchronized before it receives any request, so the
+	// existing container is reconciled first.
+	adjustment := createContainer(t, r, p, pod, created)
+
+	r.mu.Lock()
+	syncUpdates := r.syncUpdates
+	r.mu.Unlock()
+	if len(syncUpdates) != 1 || syncUpdates[0].GetContainerId() != existing.Id {
+		t.Fatalf("Synchronize updates = %v, want one for %s", syncUpdates, existing.Id)
+	}
+	if got := syncUpdates[0].GetLinux().GetResources().GetMemory().GetLimit().GetValue(); got != 1<<30 {
+		t.Errorf("Synchronize memory limit = %d, want %d", got, 1<<30)
+	}
+
+	if got := adjustment.GetLinux().GetResources().GetMemory().GetLimit().GetValue(); got != 1<<29 {
+		t.Errorf("CreateContainer memory limit = %d, want %d", got, 1<<29)
+	}
+
+	// The container is tracked once the runtime reports it created.
+	if isTracked(p, created) {
+		t.Fatalf("container %s tracked before it was created", created.Id)
+	}
+	if err := r.PostCreateContainer(context.Background(), &api.StateChangeEvent{Pod: pod, Container: created}); err != nil {
+		t.Fatalf("PostCreateContainer: %v", err)
+	}
+	if !isTracked(p, created) {
+		t.Fatalf("container %s not tracked after it was created", created.Id)
+	}
+
+	// Editing the config on the node updates the running container.
+	writeMemoryLimit(t, specDir, newRef, pod, 1<<28)
+	p.updateContainers()
+
+	r.mu.Lock()
+	updates := r.updates
+	r.mu.Unlock()
+	if len(updates) != 1 || updates[0].GetContainerId() != created.Id {
+		t.Fatalf("UpdateContainers updates = %v, want one for %s", updates, created.Id)
+	}
+	if got := updates[0].GetLinux().GetResources().GetMemory().GetLimit().GetValue(); got != 1<<28 {
+		t.Errorf("UpdateContainers memory limit = %d, want %d", got, 1<<28)
+	}
+
+	// Removed containers are no longer tracked.
+	if err := r.RemoveContainer(context.Background(), &api.StateChangeEvent{Pod: pod, Container: created}); err != nil {
+		t.Fatalf("RemoveContainer: %v", err)
+	}
+	if isTracked(p, created) {
+		t.Errorf("container %s still tracked after removal", created.Id)
+	}
+}
+
+// isTracked reports whether p tracks container.
+func isTracked(p *Plugin, container *api.Container) bool {
+	for _, c := range p.tracked() {
+		if c.container.GetId() == container.GetId() {
+			return true
+		}
+	}
+	return false
+}
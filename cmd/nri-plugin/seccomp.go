@@ -0,0 +1,90 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/containerd/nri/pkg/api"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+
+	configapi "runtime-spec-dra-driver/api/v1alpha1"
+)
+
+// resolveSeccompProfile returns a copy of spec with the profile referenced by
+// the seccomp profile annotation loaded from root into linux.seccomp. The
+// profile is opened within root, so neither the reference nor a symlink can
+// point outside of it. It must be called after the policy check, which
+// matches the reference against the allowed profiles.
+func resolveSeccompProfile(spec *configapi.RuntimeSpec, root string) (*configapi.RuntimeSpec, error) {
+	profile, ok := spec.Annotations[configapi.SeccompProfileAnnotation]
+	if !ok {
+		return spec, nil
+	}
+
+	r, err := os.OpenRoot(root)
+	if err != nil {
+		return nil, fmt.Errorf("unable to open seccomp profile root: %w", err)
+	}
+	defer r.Close()
+
+	f, err := r.Open(profile)
+	if err != nil {
+		return nil, fmt.Errorf("unable to open seccomp profile %s: %w", profile, err)
+	}
+	defer f.Close()
+	data, err := io.ReadAll(f)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read seccomp profile %s: %w", profile, err)
+	}
+	var seccomp configapi.LinuxSeccomp
+	if err := json.Unmarshal(data, &seccomp); err != nil {
+		return nil, fmt.Errorf("unable to decode seccomp profile %s: %w", profile, err)
+	}
+
+	spec = spec.DeepCopy()
+	delete(spec.Annotations, configapi.SeccompProfileAnnotation)
+	if spec.Linux == nil {
+		spec.Linux = &configapi.Linux{}
+	}
+	spec.Linux.Seccomp = &seccomp
+
+	if err := configapi.ValidateRuntimeSpec(spec, field.NewPath("spec")).ToAggregate(); err != nil {
+		return nil, fmt.Errorf("invalid seccomp profile %s: %w", profile, err)
+	}
+	return spec, nil
+}
+
+// convertSeccomp converts an OCI seccomp profile to an NRI seccomp policy.
+func convertSeccomp(s *configapi.LinuxSeccomp) *api.LinuxSeccomp {
+	seccomp := &api.LinuxSeccomp{
+		DefaultAction:    s.DefaultAction,
+		Architectures:    s.Architectures,
+		Flags:            s.Flags,
+		ListenerPath:     s.ListenerPath,
+		ListenerMetadata: s.ListenerMetadata,
+	}
+	if s.DefaultErrnoRet != nil {
+		seccomp.DefaultErrno = &api.OptionalUInt32{Value: uint32(*s.DefaultErrnoRet)}
+	}
+	for _, sc := range s.Syscalls {
+		syscall := &api.LinuxSyscall{
+			Names:  sc.Names,
+			Action: sc.Action,
+		}
+		if sc.ErrnoRet != nil {
+			syscall.ErrnoRet = &api.OptionalUInt32{Value: uint32(*sc.ErrnoRet)}
+		}
+		for _, arg := range sc.Args {
+			syscall.Args = append(syscall.Args, &api.LinuxSeccompArg{
+				Index:    uint32(arg.Index),
+				Value:    arg.Value,
+				ValueTwo: arg.ValueTwo,
+				Op:       arg.Op,
+			})
+		}
+		seccomp.Syscalls = append(seccomp.Syscalls, syscall)
+	}
+	return seccomp
+}
@@ -0,0 +1,153 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/containerd/nri/pkg/api"
+
+	configapi "runtime-spec-dra-driver/api/v1alpha1"
+)
+
+// synchronizeContainer reconciles a container that existed before the plugin
+// was registered with its runtime spec. It returns an update reapplying the
+// live settings, or nil if the container has no runtime spec or no live
+// settings, and reports the remaining fields the container does not match.
+func (p *Plugin) synchronizeContainer(pod *api.PodSandbox, container *api.Container) (*api.ContainerUpdate, []string, error) {
+	spec, removals, err := p.getRuntimeSpec(pod, container)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get OCI runtime spec: %w", err)
+	}
+	if spec == nil {
+		return nil, nil, nil
+	}
+	spec, err = p.prepareSpec(spec, removals)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if p.mode != ModeAudit {
+		p.track(pod, container, spec)
+	}
+
+	drift := specDrift(spec, removals, container)
+	live := liveSettingsOf(spec)
+	if live == (liveSettings{}) {
+		return nil, drift, nil
+	}
+	update, err := live.containerUpdate(container.GetId())
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create container update: %w", err)
+	}
+	return update, drift, nil
+}
+
+// specDrift returns the settings of spec and removals that cannot be updated
+// in a running container and that container does not match, e.g. because it
+// was created before the plugin was deployed.
+func specDrift(spec *configapi.RuntimeSpec, removals *configapi.Removals, container *api.Container) []string {
+	var drift []string
+	missing := func(format string, args ...any) {
+		drift = append(drift, fmt.Sprintf(format, args...))
+	}
+
+	if spec.Process != nil {
+		for _, env := range spec.Process.Env {
+			if !slices.Contains(container.GetEnv(), env) {
+				missing("process.env %s", env)
+			}
+		}
+		for _, r := range spec.Process.Rlimits {
+			if !slices.ContainsFunc(container.GetRlimits(), func(cr *api.POSIXRlimit) bool {
+				return cr.GetType() == r.Type && cr.GetHard() == r.Hard && cr.GetSoft() == r.Soft
+			}) {
+				missing("process.rlimits %s", r.Type)
+			}
+		}
+		if adj := spec.Process.OOMScoreAdj; adj != nil {
+			if current := container.GetLinux().GetOomScoreAdj(); current == nil || current.GetValue() != int64(*adj) {
+				missing("process.oomScoreAdj %d", *adj)
+			}
+		}
+	}
+
+	for _, m := range spec.Mounts {
+		if !slices.ContainsFunc(container.GetMounts(), func(cm *api.Mount) bool {
+			return cm.GetDestination() == m.Destination && cm.GetSource() == m.Source
+		}) {
+			missing("mounts %s", m.Destination)
+		}
+	}
+
+	if spec.Hooks != nil {
+		hooks := container.GetHooks()
+		for _, stage := range []struct {
+			name    string
+			want    []configapi.Hook
+			current []*api.Hook
+		}{
+			{"prestart", spec.Hooks.Prestart, hooks.GetPrestart()},
+			{"createRuntime", spec.Hooks.CreateRuntime, hooks.GetCreateRuntime()},
+			{"createContainer", spec.Hooks.CreateContainer, hooks.GetCreateContainer()},
+			{"startContainer", spec.Hooks.StartContainer, hooks.GetStartContainer()},
+			{"poststart", spec.Hooks.Poststart, hooks.GetPoststart()},
+			{"poststop", spec.Hooks.Poststop, hooks.GetPoststop()},
+		} {
+			for _, h := range stage.want {
+				if !slices.ContainsFunc(stage.current, func(ch *api.Hook) bool { return ch.GetPath() == h.Path }) {
+					missing("hooks.%s %s", stage.name, h.Path)
+				}
+			}
+		}
+	}
+
+	if spec.Linux != nil {
+		linux := container.GetLinux()
+		for _, d := range spec.Linux.Devices {
+			if !slices.ContainsFunc(linux.GetDevices(), func(cd *api.LinuxDevice) bool { return cd.GetPath() == d.Path }) {
+				missing("linux.devices %s", d.Path)
+			}
+		}
+		for key, value := range spec.Linux.Sysctl {
+			if current, ok := linux.GetSysctl()[key]; !ok || current != value {
+				missing("linux.sysctl %s", key)
+			}
+		}
+		for _, ns := range spec.Linux.Namespaces {
+			if !slices.ContainsFunc(linux.GetNamespaces(), func(cn *api.LinuxNamespace) bool {
+				return cn.GetType() == ns.Type && cn.GetPath() == ns.Path
+			}) {
+				missing("linux.namespaces %s", ns.Type)
+			}
+		}
+	}
+
+	if removals != nil {
+		for _, name := range removals.Env {
+			if slices.ContainsFunc(container.GetEnv(), func(env string) bool {
+				return env == name || strings.HasPrefix(env, name+"=")
+			}) {
+				missing("remove.env %s", name)
+			}
+		}
+		for _, destination := range removals.Mounts {
+			if slices.ContainsFunc(container.GetMounts(), func(m *api.Mount) bool { return m.GetDestination() == destination }) {
+				missing("remove.mounts %s", destination)
+			}
+		}
+		for _, path := range removals.Devices {
+			if slices.ContainsFunc(container.GetLinux().GetDevices(), func(d *api.LinuxDevice) bool { return d.GetPath() == path }) {
+				missing("remove.devices %s", path)
+			}
+		}
+		for _, key := range removals.Annotations {
+			if _, ok := container.GetAnnotations()[key]; ok {
+				missing("remove.annotations %s", key)
+			}
+		}
+	}
+
+	slices.Sort(drift)
+	return drift
+}
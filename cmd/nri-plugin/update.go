@@ -0,0 +1,242 @@
+package main
+
+import (
+	"context"
+	"reflect"
+	"sync"
+	"time"
+
+	"github.com/containerd/nri/pkg/api"
+	"k8s.io/klog/v2"
+
+	configapi "runtime-spec-dra-driver/api/v1alpha1"
+)
+
+// trackedContainer is a running container adjusted by the plugin.
+type trackedContainer struct {
+	pod       *api.PodSandbox
+	container *api.Container
+	// spec is the spec last applied to the container.
+	spec *configapi.RuntimeSpec
+	// drifted is set once drift of the container has been reported, until its
+	// cgroup files match spec again.
+	drifted bool
+}
+
+// containerIndex holds the containers adjusted by the plugin, keyed by
+// container ID. Their config files are watched for changes and their cgroups
+// for drift.
+type containerIndex struct {
+	sync.Mutex
+	containers map[string]*trackedContainer
+	// pending holds the containers adjusted in CreateContainer until the
+	// runtime reports them created. Containers whose creation fails are
+	// removed, or never reported, so they are not watched.
+	pending map[string]*trackedContainer
+}
+
+// track records spec as applied to container.
+func (p *Plugin) track(pod *api.PodSandbox, container *api.Container, spec *configapi.RuntimeSpec) {
+	p.index.Lock()
+	defer p.index.Unlock()
+	if p.index.containers == nil {
+		p.index.containers = make(map[string]*trackedContainer)
+	}
+	p.index.containers[container.GetId()] = &trackedContainer{pod: pod, container: container, spec: spec}
+}
+
+// trackPending records spec as applied to container, which is being created.
+// It is tracked once PostCreateContainer reports it created.
+func (p *Plugin) trackPending(pod *api.PodSandbox, container *api.Container, spec *configapi.RuntimeSpec) {
+	p.index.Lock()
+	defer p.index.Unlock()
+	if p.index.pending == nil {
+		p.index.pending = make(map[string]*trackedContainer)
+	}
+	p.index.pending[container.GetId()] = &trackedContainer{pod: pod, container: container, spec: spec}
+}
+
+// untrack forgets container.
+func (p *Plugin) untrack(container *api.Container) {
+	p.index.Lock()
+	defer p.index.Unlock()
+	delete(p.index.containers, container.GetId())
+	delete(p.index.pending, container.GetId())
+}
+
+// tracked returns the containers currently tracked.
+func (p *Plugin) tracked() []*trackedContainer {
+	p.index.Lock()
+	defer p.index.Unlock()
+	containers := make([]*trackedContainer, 0, len(p.index.containers))
+	for _, c := range p.index.containers {
+		containers = append(containers, c)
+	}
+	return containers
+}
+
+// PostCreateContainer is called when a container has been created. It starts
+// tracking the container if CreateContainer adjusted it.
+func (p *Plugin) PostCreateContainer(_ context.Context, pod *api.PodSandbox, container *api.Container) error {
+	p.index.Lock()
+	defer p.index.Unlock()
+	c, ok := p.index.pending[container.GetId()]
+	if !ok {
+		return nil
+	}
+	delete(p.index.pending, container.GetId())
+	klog.V(2).Infof("Tracking container %s in pod %s/%s", container.GetName(), pod.GetNamespace(), pod.GetName())
+	if p.index.containers == nil {
+		p.index.containers = make(map[string]*trackedContainer)
+	}
+	p.index.containers[container.GetId()] = &trackedContainer{pod: pod, container: container, spec: c.spec}
+	return nil
+}
+
+// RemoveContainer is called when a container has been removed.
+func (p *Plugin) RemoveContainer(_ context.Context, pod *api.PodSandbox, container *api.Container) error {
+	klog.V(2).Infof("RemoveContainer called: pod=%s/%s, container=%s",
+		pod.GetNamespace(), pod.GetName(), container.GetName())
+	p.untrack(container)
+	p.inlineIgnored.Delete(container.GetId())
+	return nil
+}
+
+// watchConfigs rereads the config files of the tracked containers every
+// interval until ctx is done, and updates the containers whose effective spec
+// has changed. Changes to the settings come from edits made on the node: the
+// DRA plugin only rewrites a config file to update its owner when a shared
+// claim is reserved for other pods, which leaves the spec, and therefore the
+// container, unchanged.
+func (p *Plugin) watchConfigs(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			p.updateContainers()
+		}
+	}
+}
+
+// updateContainers sends updates for the tracked containers whose live
+// settings differ from the ones last applied. Only resources, the RDT class
+// and the blockio class can be changed in a running container. Changes to
+// other fields are logged and take effect when the container is recreated.
+func (p *Plugin) updateContainers() {
+	var updates []*api.ContainerUpdate
+	specs := make(map[string]*configapi.RuntimeSpec)
+
+	for _, c := range p.tracked() {
+		pod, container := c.pod, c.container
+		spec, removals, err := p.getRuntimeSpec(pod, container)
+		if err == nil {
+			spec, err = p.prepareSpec(spec, removals)
+		}
+		if err != nil {
+			klog.Errorf("Failed to get updated OCI runtime spec for container %s in pod %s/%s, keeping current settings: %v",
+				container.GetName(), pod.GetNamespace(), pod.GetName(), err)
+			continue
+		}
+		if reflect.DeepEqual(spec, c.spec) {
+			continue
+		}
+
+		applied, changed := liveSettingsOf(c.spec), liveSettingsOf(spec)
+		if !reflect.DeepEqual(withoutLiveSettings(spec), withoutLiveSettings(c.spec)) {
+			klog.Warningf("OCI runtime spec for container %s in pod %s/%s changed in fields that cannot be updated in a running container, they take effect when it is recreated",
+				container.GetName(), pod.GetNamespace(), pod.GetName())
+		}
+		if reflect.DeepEqual(applied, changed) {
+			continue
+		}
+
+		update, err := changed.containerUpdate(container.GetId())
+		if err != nil {
+			klog.Errorf("Failed to create update for container %s in pod %s/%s: %v",
+				container.GetName(), pod.GetNamespace(), pod.GetName(), err)
+			continue
+		}
+		klog.Infof("Updating container %s in pod %s/%s: unified=%v",
+			container.GetName(), pod.GetNamespace(), pod.GetName(), update.GetLinux().GetResources().GetUnified())
+		updates = append(updates, update)
+		specs[container.GetId()] = spec
+	}
+
+	if len(updates) == 0 {
+		return
+	}
+	failed, err := p.stub.UpdateContainers(updates)
+	if err != nil {
+		klog.Errorf("Failed to update containers: %v", err)
+		return
+	}
+	for _, update := range failed {
+		klog.Errorf("Failed to update container %s", update.GetContainerId())
+		delete(specs, update.GetContainerId())
+	}
+
+	// Record the applied specs unless the container was removed meanwhile.
+	p.index.Lock()
+	defer p.index.Unlock()
+	for id, spec := range specs {
+		if c, ok := p.index.containers[id]; ok {
+			c.spec = spec
+		}
+	}
+}
+
+// liveSettings are the parts of a spec that can be updated in a running
+// container.
+type liveSettings struct {
+	resources    *configapi.LinuxResources
+	rdtClass     string
+	blockIOClass string
+}
+
+func liveSettingsOf(spec *configapi.RuntimeSpec) liveSettings {
+	var s liveSettings
+	if spec.Linux != nil {
+		s.resources = spec.Linux.Resources
+		if spec.Linux.IntelRdt != nil {
+			s.rdtClass = spec.Linux.IntelRdt.ClosID
+		}
+	}
+	s.blockIOClass = spec.Annotations[configapi.BlockIOClassAnnotation]
+	return s
+}
+
+// withoutLiveSettings returns a copy of spec with the live settings cleared.
+func withoutLiveSettings(spec *configapi.RuntimeSpec) *configapi.RuntimeSpec {
+	spec = spec.DeepCopy()
+	if spec.Linux != nil {
+		spec.Linux.Resources = nil
+		spec.Linux.IntelRdt = nil
+	}
+	delete(spec.Annotations, configapi.BlockIOClassAnnotation)
+	if len(spec.Annotations) == 0 {
+		spec.Annotations = nil
+	}
+	return spec
+}
+
+// containerUpdate creates an update setting s for the container with id.
+func (s liveSettings) containerUpdate(id string) (*api.ContainerUpdate, error) {
+	update := &api.ContainerUpdate{ContainerId: id}
+	if s.resources != nil {
+		resources, _, err := convertResources(s.resources)
+		if err != nil {
+			return nil, err
+		}
+		update.Linux = &api.LinuxContainerUpdate{Resources: resources}
+	}
+	if s.rdtClass != "" {
+		update.SetLinuxRDTClass(s.rdtClass)
+	}
+	if s.blockIOClass != "" {
+		update.SetLinuxBlockIOClass(s.blockIOClass)
+	}
+	return update, nil
+}
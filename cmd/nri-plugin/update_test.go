@@ -0,0 +1,78 @@
+package main
+
+import (
+	"context"
+	"testing"
+
+	"k8s.io/utils/ptr"
+
+	configapi "runtime-spec-dra-driver/api/v1alpha1"
+	"runtime-spec-dra-driver/pkg/handoff"
+)
+
+func TestUpdateContainers(t *testing.T) {
+	tests := []struct {
+		name string
+		// notCreated is set for containers the runtime fails to create.
+		notCreated bool
+		// edit changes the config file of the container.
+		edit       func(f *handoff.File)
+		wantUpdate bool
+	}{
+		{
+			// The DRA plugin rewrites the owner when a shared claim is
+			// reserved for more pods.
+			name: "owner changed",
+			edit: func(f *handoff.File) {
+				f.Owner.PodUIDs = append(f.Owner.PodUIDs, "other-pod-uid")
+			},
+		},
+		{
+			name: "memory limit changed",
+			edit: func(f *handoff.File) {
+				f.Config.Spec.Linux.Resources.Memory.Limit = ptr.To[int64](1 << 28)
+			},
+			wantUpdate: true,
+		},
+		{
+			name:       "container not created",
+			notCreated: true,
+			edit: func(f *handoff.File) {
+				f.Config.Spec.Linux.Resources.Memory.Limit = ptr.To[int64](1 << 28)
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := &fakeStub{}
+			p := &Plugin{stub: fake, specDir: t.TempDir(), cgroup2: true, mode: ModeEnforce, failurePolicy: configapi.FailurePolicyFailClosed}
+			pod, container := newTestContainer(t, p.specDir, &configapi.RuntimeSpecEditConfig{Spec: &configapi.RuntimeSpec{
+				Linux: &configapi.Linux{Resources: &configapi.LinuxResources{Memory: &configapi.LinuxMemory{Limit: ptr.To[int64](1 << 29)}}},
+			}})
+			if _, _, err := p.CreateContainer(context.Background(), pod, container); err != nil {
+				t.Fatalf("CreateContainer: %v", err)
+			}
+			if !tt.notCreated {
+				if err := p.PostCreateContainer(context.Background(), pod, container); err != nil {
+					t.Fatalf("PostCreateContainer: %v", err)
+				}
+			}
+
+			ref := handoff.Ref{ClaimUID: "claim-uid", Device: "runtime-spec-0"}
+			f, err := handoff.Read(p.specDir, ref)
+			if err != nil {
+				t.Fatalf("handoff.Read: %v", err)
+			}
+			tt.edit(f)
+			if _, err := handoff.Write(p.specDir, ref, f); err != nil {
+				t.Fatalf("handoff.Write: %v", err)
+			}
+
+			p.updateContainers()
+
+			if updated := len(fake.updates) > 0; updated != tt.wantUpdate {
+				t.Errorf("updates = %v, want update %v", fake.updates, tt.wantUpdate)
+			}
+		})
+	}
+}
@@ -0,0 +1,178 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+
+	admissionv1 "k8s.io/api/admission/v1"
+	resourceapi "k8s.io/api/resource/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/serializer"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"k8s.io/klog/v2"
+
+	configapi "runtime-spec-dra-driver/api/v1alpha1"
+	"runtime-spec-dra-driver/pkg/policy"
+)
+
+var (
+	resourceClaimResource = metav1.GroupVersionResource{
+		Group:    resourceapi.SchemeGroupVersion.Group,
+		Version:  resourceapi.SchemeGroupVersion.Version,
+		Resource: "resourceclaims",
+	}
+	resourceClaimTemplateResource = metav1.GroupVersionResource{
+		Group:    resourceapi.SchemeGroupVersion.Group,
+		Version:  resourceapi.SchemeGroupVersion.Version,
+		Resource: "resourceclaimtemplates",
+	}
+)
+
+var deserializer runtime.Decoder
+
+func init() {
+	scheme := runtime.NewScheme()
+	utilruntime.Must(admissionv1.AddToScheme(scheme))
+	utilruntime.Must(resourceapi.AddToScheme(scheme))
+	deserializer = serializer.NewCodecFactory(scheme).UniversalDeserializer()
+}
+
+// validator checks the RuntimeSpecEditConfigs embedded in ResourceClaims and
+// ResourceClaimTemplates using the same validation and policy logic that the
+// kubelet plugin applies when a claim is prepared.
+type validator struct {
+	policy          *policy.Policy
+	clusterPolicies *policy.ClusterPolicies
+}
+
+func (v *validator) serveResourceClaim(w http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if contentType := req.Header.Get("Content-Type"); contentType != "application/json" {
+		http.Error(w, fmt.Sprintf("unsupported content type %q, expected application/json", contentType), http.StatusUnsupportedMediaType)
+		return
+	}
+
+	body, err := io.ReadAll(req.Body)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("unable to read request body: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	obj, gvk, err := deserializer.Decode(body, nil, nil)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("unable to decode request body: %v", err), http.StatusBadRequest)
+		return
+	}
+	review, ok := obj.(*admissionv1.AdmissionReview)
+	if !ok || review.Request == nil {
+		http.Error(w, fmt.Sprintf("unsupported request of kind %v", gvk), http.StatusBadRequest)
+		return
+	}
+
+	response := &admissionv1.AdmissionReview{
+		TypeMeta: review.TypeMeta,
+		Response: v.admitResourceClaimParameters(review.Request),
+	}
+	response.Response.UID = review.Request.UID
+
+	out, err := json.Marshal(response)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("unable to encode response: %v", err), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if _, err := w.Write(out); err != nil {
+		klog.Errorf("Failed to write admission response: %v", err)
+	}
+}
+
+// admitResourceClaimParameters accepts or rejects a ResourceClaim or
+// ResourceClaimTemplate based on the opaque configs it carries for this driver.
+func (v *validator) admitResourceClaimParameters(req *admissionv1.AdmissionRequest) *admissionv1.AdmissionResponse {
+	var configs []resourceapi.DeviceClaimConfiguration
+	var fldPath *field.Path
+
+	switch req.Resource {
+	case resourceClaimResource:
+		var claim resourceapi.ResourceClaim
+		if err := runtime.DecodeInto(deserializer, req.Object.Raw, &claim); err != nil {
+			return errorResponse(fmt.Errorf("unable to decode ResourceClaim: %w", err))
+		}
+		configs = claim.Spec.Devices.Config
+		fldPath = field.NewPath("spec", "devices", "config")
+	case resourceClaimTemplateResource:
+		var template resourceapi.ResourceClaimTemplate
+		if err := runtime.DecodeInto(deserializer, req.Object.Raw, &template); err != nil {
+			return errorResponse(fmt.Errorf("unable to decode ResourceClaimTemplate: %w", err))
+		}
+		configs = template.Spec.Spec.Devices.Config
+		fldPath = field.NewPath("spec", "spec", "devices", "config")
+	default:
+		return errorResponse(fmt.Errorf("expected resource to be %s or %s, got %s", resourceClaimResource, resourceClaimTemplateResource, req.Resource))
+	}
+
+	var allErrs field.ErrorList
+	for i, config := range configs {
+		if config.Opaque == nil || config.Opaque.Driver != DriverName {
+			continue
+		}
+		paramsPath := fldPath.Index(i).Child("opaque", "parameters")
+		if err := v.validateParameters(req.Namespace, config.Opaque.Parameters.Raw); err != nil {
+			allErrs = append(allErrs, field.Invalid(paramsPath, field.OmitValueType{}, err.Error()))
+		}
+	}
+
+	if len(allErrs) > 0 {
+		klog.V(2).Infof("Rejecting %s %s/%s: %v", req.Kind.Kind, req.Namespace, req.Name, allErrs.ToAggregate())
+		return errorResponse(allErrs.ToAggregate())
+	}
+	return &admissionv1.AdmissionResponse{Allowed: true}
+}
+
+// validateParameters decodes, normalizes and validates a single opaque config
+// and checks it against the configured policies.
+func (v *validator) validateParameters(namespace string, raw []byte) error {
+	decoded, err := runtime.Decode(configapi.Decoder, raw)
+	if err != nil {
+		return fmt.Errorf("error decoding config parameters: %w", configapi.DescribeDecodeError(err))
+	}
+
+	config, ok := decoded.(*configapi.RuntimeSpecEditConfig)
+	if !ok {
+		return fmt.Errorf("expected a %s but got %T", configapi.RuntimeSpecEditConfigKind, decoded)
+	}
+	if err := config.Normalize(); err != nil {
+		return fmt.Errorf("error normalizing config: %w", err)
+	}
+	if err := config.Validate(); err != nil {
+		return fmt.Errorf("error validating config: %w", err)
+	}
+
+	if err := v.policy.CheckConfig(config); err != nil {
+		return fmt.Errorf("config denied by policy: %w", err)
+	}
+	if v.clusterPolicies != nil {
+		if _, err := v.clusterPolicies.Check(namespace, config); err != nil {
+			return fmt.Errorf("config denied by RuntimeSpecPolicy: %w", err)
+		}
+	}
+
+	return nil
+}
+
+func errorResponse(err error) *admissionv1.AdmissionResponse {
+	return &admissionv1.AdmissionResponse{
+		Allowed: false,
+		Result: &metav1.Status{
+			Message: err.Error(),
+			Reason:  metav1.StatusReasonInvalid,
+		},
+	}
+}
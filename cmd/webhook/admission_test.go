@@ -0,0 +1,217 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	admissionv1 "k8s.io/api/admission/v1"
+	corev1 "k8s.io/api/core/v1"
+	resourceapi "k8s.io/api/resource/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	"k8s.io/client-go/kubernetes/fake"
+
+	configapi "runtime-spec-dra-driver/api/v1alpha1"
+	"runtime-spec-dra-driver/pkg/policy"
+)
+
+// newTestClusterPolicies returns ClusterPolicies serving a policy that
+// selects namespaces labeled runtime-spec=allowed and allows only
+// memory.high, and the namespaces "team-a" (selected) and "team-b".
+func newTestClusterPolicies(t *testing.T) *policy.ClusterPolicies {
+	t.Helper()
+	rsp := &unstructured.Unstructured{Object: map[string]any{
+		"apiVersion": configapi.GroupName + "/" + configapi.Version,
+		"kind":       configapi.RuntimeSpecPolicyKind,
+		"metadata":   map[string]any{"name": "memory-only"},
+		"spec": map[string]any{
+			"namespaceSelector": map[string]any{"matchLabels": map[string]any{"runtime-spec": "allowed"}},
+			"unified":           []any{map[string]any{"key": "memory.high"}},
+		},
+	}}
+	dynamicclient := dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(),
+		map[schema.GroupVersionResource]string{policy.RuntimeSpecPolicyResource: configapi.RuntimeSpecPolicyKind + "List"},
+		rsp)
+	coreclient := fake.NewSimpleClientset(
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "team-a", Labels: map[string]string{"runtime-spec": "allowed"}}},
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "team-b"}},
+	)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	clusterPolicies, err := policy.NewClusterPolicies(ctx, coreclient, dynamicclient)
+	if err != nil {
+		t.Fatalf("NewClusterPolicies: %v", err)
+	}
+	return clusterPolicies
+}
+
+// newReview returns an AdmissionReview for a ResourceClaim in namespace
+// carrying an opaque config with parameters for driver.
+func newReview(t *testing.T, namespace, driver, parameters string) []byte {
+	t.Helper()
+	claim := resourceapi.ResourceClaim{
+		TypeMeta:   metav1.TypeMeta{APIVersion: resourceapi.SchemeGroupVersion.String(), Kind: "ResourceClaim"},
+		ObjectMeta: metav1.ObjectMeta{Name: "claim", Namespace: namespace},
+		Spec: resourceapi.ResourceClaimSpec{Devices: resourceapi.DeviceClaim{
+			Config: []resourceapi.DeviceClaimConfiguration{{
+				DeviceConfiguration: resourceapi.DeviceConfiguration{Opaque: &resourceapi.OpaqueDeviceConfiguration{
+					Driver:     driver,
+					Parameters: runtime.RawExtension{Raw: []byte(parameters)},
+				}},
+			}},
+		}},
+	}
+	raw, err := json.Marshal(claim)
+	if err != nil {
+		t.Fatal(err)
+	}
+	review := admissionv1.AdmissionReview{
+		TypeMeta: metav1.TypeMeta{APIVersion: admissionv1.SchemeGroupVersion.String(), Kind: "AdmissionReview"},
+		Request: &admissionv1.AdmissionRequest{
+			UID:       "review-uid",
+			Kind:      metav1.GroupVersionKind{Group: resourceapi.GroupName, Version: "v1beta1", Kind: "ResourceClaim"},
+			Resource:  resourceClaimResource,
+			Namespace: namespace,
+			Name:      claim.Name,
+			Operation: admissionv1.Create,
+			Object:    runtime.RawExtension{Raw: raw},
+		},
+	}
+	body, err := json.Marshal(review)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return body
+}
+
+func TestServeResourceClaim(t *testing.T) {
+	config := func(unified string) string {
+		return `{"apiVersion":"` + configapi.GroupName + `/` + configapi.Version + `","kind":"` + configapi.RuntimeSpecEditConfigKind +
+			`","spec":{"linux":{"resources":{"unified":{` + unified + `}}}}}`
+	}
+	nodePolicy := &policy.Policy{RuntimeSpecPolicyRules: configapi.RuntimeSpecPolicyRules{
+		DeniedFields: []string{"linux.resources.unified"},
+	}}
+
+	tests := []struct {
+		name            string
+		body            []byte
+		nodePolicy      *policy.Policy
+		clusterPolicies bool
+		// wantStatus is the HTTP status, the review is only checked for 200.
+		wantStatus  int
+		wantAllowed bool
+		wantMessage string
+	}{
+		{
+			name:        "allowed claim",
+			body:        newReview(t, "team-a", DriverName, config(`"memory.high":"1048576"`)),
+			wantStatus:  http.StatusOK,
+			wantAllowed: true,
+		},
+		{
+			name:        "invalid spec",
+			body:        newReview(t, "team-a", DriverName, config(`"memory.high":"lots"`)),
+			wantStatus:  http.StatusOK,
+			wantMessage: "error validating config",
+		},
+		{
+			name:        "unknown spec field",
+			body:        newReview(t, "team-a", DriverName, `{"apiVersion":"`+configapi.GroupName+`/`+configapi.Version+`","kind":"`+configapi.RuntimeSpecEditConfigKind+`","spec":{"process":{"args":["sh"]}}}`),
+			wantStatus:  http.StatusOK,
+			wantMessage: `unknown field "spec.process.args"`,
+		},
+		{
+			name:        "denied by node policy",
+			body:        newReview(t, "team-a", DriverName, config(`"memory.high":"1048576"`)),
+			nodePolicy:  nodePolicy,
+			wantStatus:  http.StatusOK,
+			wantMessage: "config denied by policy",
+		},
+		{
+			name:            "allowed by cluster policy",
+			body:            newReview(t, "team-a", DriverName, config(`"memory.high":"1048576"`)),
+			clusterPolicies: true,
+			wantStatus:      http.StatusOK,
+			wantAllowed:     true,
+		},
+		{
+			name:            "denied by cluster policy",
+			body:            newReview(t, "team-a", DriverName, config(`"pids.max":"100"`)),
+			clusterPolicies: true,
+			wantStatus:      http.StatusOK,
+			wantMessage:     `RuntimeSpecPolicy "memory-only"`,
+		},
+		{
+			name:            "no cluster policy for namespace",
+			body:            newReview(t, "team-b", DriverName, config(`"memory.high":"1048576"`)),
+			clusterPolicies: true,
+			wantStatus:      http.StatusOK,
+			wantMessage:     `no RuntimeSpecPolicy applies to namespace "team-b"`,
+		},
+		{
+			name:        "foreign driver config is ignored",
+			body:        newReview(t, "team-a", "gpu.example.com", `{"anything":true}`),
+			nodePolicy:  nodePolicy,
+			wantStatus:  http.StatusOK,
+			wantAllowed: true,
+		},
+		{
+			name:       "malformed body",
+			body:       []byte(`{"apiVersion":`),
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "not an AdmissionReview",
+			body:       []byte(`{"apiVersion":"v1","kind":"Namespace","metadata":{"name":"team-a"}}`),
+			wantStatus: http.StatusBadRequest,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v := &validator{policy: tt.nodePolicy}
+			if tt.clusterPolicies {
+				v.clusterPolicies = newTestClusterPolicies(t)
+			}
+
+			req := httptest.NewRequest(http.MethodPost, ValidatePath, bytes.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			rec := httptest.NewRecorder()
+			newMux(v).ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.wantStatus, rec.Body)
+			}
+			if tt.wantStatus != http.StatusOK {
+				return
+			}
+
+			var review admissionv1.AdmissionReview
+			if err := json.Unmarshal(rec.Body.Bytes(), &review); err != nil {
+				t.Fatalf("unable to decode response: %v", err)
+			}
+			response := review.Response
+			if response == nil {
+				t.Fatal("response missing")
+			}
+			if response.UID != "review-uid" {
+				t.Errorf("UID = %q, want %q", response.UID, "review-uid")
+			}
+			if response.Allowed != tt.wantAllowed {
+				t.Fatalf("Allowed = %v, want %v: %v", response.Allowed, tt.wantAllowed, response.Result)
+			}
+			if tt.wantMessage != "" && (response.Result == nil || !strings.Contains(response.Result.Message, tt.wantMessage)) {
+				t.Errorf("Result = %v, want message containing %q", response.Result, tt.wantMessage)
+			}
+		})
+	}
+}
@@ -0,0 +1,165 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"os/signal"
+	"strconv"
+	"syscall"
+	"time"
+
+	"github.com/urfave/cli/v2"
+
+	"k8s.io/klog/v2"
+
+	"runtime-spec-dra-driver/pkg/flags"
+	"runtime-spec-dra-driver/pkg/policy"
+)
+
+const (
+	DriverName = "runtime-spec.io"
+
+	ValidatePath = "/validate-resource-claim-parameters"
+	ReadyzPath   = "/readyz"
+)
+
+type Flags struct {
+	kubeClientConfig flags.KubeClientConfig
+	loggingConfig    *flags.LoggingConfig
+
+	certFile          string
+	keyFile           string
+	port              int
+	policyFile        string
+	namespacePolicies bool
+}
+
+func main() {
+	if err := newApp().Run(os.Args); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func newApp() *cli.App {
+	flags := &Flags{
+		loggingConfig: flags.NewLoggingConfig(),
+	}
+	cliFlags := []cli.Flag{
+		&cli.StringFlag{
+			Name:        "tls-cert-file",
+			Usage:       "File containing the default x509 Certificate for HTTPS. (CA cert, if any, concatenated after server cert).",
+			Destination: &flags.certFile,
+			Required:    true,
+		},
+		&cli.StringFlag{
+			Name:        "tls-private-key-file",
+			Usage:       "File containing the default x509 private key matching --tls-cert-file.",
+			Destination: &flags.keyFile,
+			Required:    true,
+		},
+		&cli.IntFlag{
+			Name:        "port",
+			Usage:       "Secure port that the webhook listens on.",
+			Value:       443,
+			Destination: &flags.port,
+		},
+		&cli.StringFlag{
+			Name:        "policy-file",
+			Usage:       "Absolute path to a policy file restricting which runtime spec fields claims may set. When empty, all supported fields are allowed.",
+			Destination: &flags.policyFile,
+			EnvVars:     []string{"POLICY_FILE"},
+		},
+		&cli.BoolFlag{
+			Name:        "namespace-policies",
+			Usage:       "Require claims to be admitted by a cluster-scoped RuntimeSpecPolicy selecting their namespace.",
+			Destination: &flags.namespacePolicies,
+			EnvVars:     []string{"NAMESPACE_POLICIES"},
+		},
+	}
+	cliFlags = append(cliFlags, flags.kubeClientConfig.Flags()...)
+	cliFlags = append(cliFlags, flags.loggingConfig.Flags()...)
+
+	app := &cli.App{
+		Name:            "runtime-spec-dra-webhook",
+		Usage:           "runtime-spec-dra-webhook validates RuntimeSpecEditConfigs in ResourceClaims and ResourceClaimTemplates.",
+		ArgsUsage:       " ",
+		HideHelpCommand: true,
+		Flags:           cliFlags,
+		Before: func(c *cli.Context) error {
+			if c.Args().Len() > 0 {
+				return fmt.Errorf("arguments not supported: %v", c.Args().Slice())
+			}
+			return flags.loggingConfig.Apply()
+		},
+		Action: func(c *cli.Context) error {
+			ctx, stop := signal.NotifyContext(c.Context, syscall.SIGHUP, syscall.SIGINT, syscall.SIGTERM, syscall.SIGQUIT)
+			defer stop()
+
+			validator := &validator{}
+			if flags.policyFile != "" {
+				p, err := policy.Load(flags.policyFile)
+				if err != nil {
+					return fmt.Errorf("load policy: %w", err)
+				}
+				validator.policy = p
+			}
+			if flags.namespacePolicies {
+				clientSets, err := flags.kubeClientConfig.NewClientSets()
+				if err != nil {
+					return fmt.Errorf("create client: %v", err)
+				}
+				validator.clusterPolicies, err = policy.NewClusterPolicies(ctx, clientSets.Core, clientSets.Dynamic)
+				if err != nil {
+					return fmt.Errorf("start RuntimeSpecPolicy informer: %w", err)
+				}
+			}
+
+			return serve(ctx, flags, newMux(validator))
+		},
+	}
+
+	return app
+}
+
+func newMux(v *validator) *http.ServeMux {
+	mux := http.NewServeMux()
+	mux.HandleFunc(ValidatePath, v.serveResourceClaim)
+	mux.HandleFunc(ReadyzPath, func(w http.ResponseWriter, req *http.Request) {
+		_, err := w.Write([]byte("ok"))
+		if err != nil {
+			klog.Errorf("Failed to write response: %v", err)
+		}
+	})
+	return mux
+}
+
+func serve(ctx context.Context, flags *Flags, mux *http.ServeMux) error {
+	server := &http.Server{
+		Handler:           mux,
+		Addr:              ":" + strconv.Itoa(flags.port),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	errCh := make(chan error, 1)
+	go func() {
+		klog.Infof("Starting webhook server on %s", server.Addr)
+		errCh <- server.ListenAndServeTLS(flags.certFile, flags.keyFile)
+	}()
+
+	select {
+	case err := <-errCh:
+		return err
+	case <-ctx.Done():
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := server.Shutdown(shutdownCtx); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
@@ -106,3 +106,14 @@ Create the name of the service account to use for the webhook
 {{- default "default-webhook" .Values.webhook.serviceAccount.name }}
 {{- end }}
 {{- end }}
+
+{{/*
+Name of the secret holding the webhook serving certificate
+*/}}
+{{- define "runtime-spec-dra-driver.webhookCertSecretName" -}}
+{{- if .Values.webhook.tls.certManager }}
+{{- printf "%s-webhook-cert" (include "runtime-spec-dra-driver.fullname" .) }}
+{{- else }}
+{{- required "webhook.tls.secretName is required when webhook.tls.certManager is false" .Values.webhook.tls.secretName }}
+{{- end }}
+{{- end }}
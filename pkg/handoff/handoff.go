@@ -0,0 +1,210 @@
+// Package handoff implements the file based exchange of RuntimeSpecEditConfigs
+// between the kubelet plugin and the NRI plugin.
+//
+// When a claim is prepared, the kubelet plugin writes the config for each
+// allocated device to <dir>/<claimUID>/<device>.json and injects only a short
+// reference ("<claimUID>/<device>") into the container via CDI. The NRI plugin
+// resolves that reference back to the file in CreateContainer. This keeps the
+// spec out of the workload's environment and is not subject to env size limits.
+//
+// Every reference is carried in its own environment variable (see Ref.EnvKey)
+// so that a container consuming several claims or devices receives all of them.
+//
+// References are set in the environment, which pod authors control as well, so
+// each file records the namespace of its claim and the pods the claim is
+// reserved for (see Owner). The NRI plugin refuses to apply a config to any
+// other pod, which keeps a forged reference from picking up a config admitted
+// for another namespace.
+package handoff
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/runtime"
+
+	configapi "runtime-spec-dra-driver/api/v1alpha1"
+)
+
+const (
+	// EnvKeyRef is the prefix of the environment variables carrying the
+	// references to config files. They are set through CDI container edits.
+	EnvKeyRef = "OCI_RUNTIME_SPEC_REF"
+
+	// DirName is the name of the directory, below the driver plugin
+	// directory, holding the per-claim config files.
+	DirName = "specs"
+)
+
+// Ref identifies the config file written for a device of a prepared claim.
+type Ref struct {
+	ClaimUID string
+	Device   string
+}
+
+func (r Ref) String() string {
+	return r.ClaimUID + "/" + r.Device
+}
+
+// EnvKey returns the name of the environment variable carrying r. Names are
+// unique per claim and device so that CDI edits for different devices do not
+// overwrite each other.
+func (r Ref) EnvKey() string {
+	return EnvKeyRef + "_" + envKeySuffix(r.ClaimUID) + "_" + envKeySuffix(r.Device)
+}
+
+// IsEnvKey reports whether name is an environment variable carrying a reference.
+func IsEnvKey(name string) bool {
+	return name == EnvKeyRef || strings.HasPrefix(name, EnvKeyRef+"_")
+}
+
+// ParseRef parses a reference of the form "<claimUID>/<device>". Both parts
+// must be plain path elements so a reference can never point outside of the
+// config directory.
+func ParseRef(s string) (Ref, error) {
+	claimUID, device, ok := strings.Cut(s, "/")
+	if !ok || !isPathElement(claimUID) || !isPathElement(device) {
+		return Ref{}, fmt.Errorf("invalid runtime spec reference %q", s)
+	}
+	return Ref{ClaimUID: claimUID, Device: device}, nil
+}
+
+// Owner identifies the claim a config file was written for and the pods that
+// may consume it.
+type Owner struct {
+	// Namespace is the namespace of the claim.
+	Namespace string `json:"namespace"`
+	// PodUIDs are the UIDs of the pods the claim is reserved for.
+	PodUIDs []string `json:"podUIDs"`
+}
+
+// Check returns an error unless the pod with podUID in namespace may consume
+// the config.
+func (o *Owner) Check(namespace, podUID string) error {
+	if o.Namespace != namespace {
+		return fmt.Errorf("config of a claim in namespace %q cannot be used by a pod in namespace %q", o.Namespace, namespace)
+	}
+	if !slices.Contains(o.PodUIDs, podUID) {
+		return fmt.Errorf("claim is not reserved for pod %s", podUID)
+	}
+	return nil
+}
+
+// File is the content of a config file.
+type File struct {
+	Owner  Owner
+	Config *configapi.RuntimeSpecEditConfig
+}
+
+// file is the encoding of File. The config is kept raw so that it can be
+// decoded with the strict API Decoder.
+type file struct {
+	Owner  Owner           `json:"owner"`
+	Config json.RawMessage `json:"config"`
+}
+
+// Path returns the location of the config file for ref below dir.
+func Path(dir string, ref Ref) string {
+	return filepath.Join(dir, ref.ClaimUID, ref.Device+".json")
+}
+
+// Write stores f as the file for ref below dir and returns its path. The file
+// is written atomically so a concurrent reader never sees a partial config.
+func Write(dir string, ref Ref, f *File) (string, error) {
+	config := f.Config.DeepCopy()
+	config.APIVersion = configapi.GroupName + "/" + configapi.Version
+	config.Kind = configapi.RuntimeSpecEditConfigKind
+
+	raw, err := json.Marshal(config)
+	if err != nil {
+		return "", fmt.Errorf("unable to encode config: %w", err)
+	}
+	data, err := json.Marshal(file{Owner: f.Owner, Config: raw})
+	if err != nil {
+		return "", fmt.Errorf("unable to encode config file: %w", err)
+	}
+
+	path := Path(dir, ref)
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return "", fmt.Errorf("unable to create config directory: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".tmp-"+ref.Device+"-")
+	if err != nil {
+		return "", fmt.Errorf("unable to create config file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return "", fmt.Errorf("unable to write config file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return "", fmt.Errorf("unable to write config file: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return "", fmt.Errorf("unable to write config file: %w", err)
+	}
+
+	return path, nil
+}
+
+// Read loads the file stored for ref below dir and normalizes its config.
+func Read(dir string, ref Ref) (*File, error) {
+	data, err := os.ReadFile(Path(dir, ref))
+	if err != nil {
+		return nil, fmt.Errorf("unable to read config file for %s: %w", ref, err)
+	}
+
+	var f file
+	if err := json.Unmarshal(data, &f); err != nil {
+		return nil, fmt.Errorf("unable to decode config file for %s: %w", ref, err)
+	}
+	if f.Owner.Namespace == "" || len(f.Config) == 0 {
+		return nil, fmt.Errorf("config file for %s has no owner, it was written by an earlier version of the driver", ref)
+	}
+
+	decoded, err := runtime.Decode(configapi.Decoder, f.Config)
+	if err != nil {
+		return nil, fmt.Errorf("unable to decode config file for %s: %w", ref, err)
+	}
+	config, ok := decoded.(*configapi.RuntimeSpecEditConfig)
+	if !ok {
+		return nil, fmt.Errorf("expected a %s in config file for %s but got %T", configapi.RuntimeSpecEditConfigKind, ref, decoded)
+	}
+	if err := config.Normalize(); err != nil {
+		return nil, fmt.Errorf("error normalizing config for %s: %w", ref, err)
+	}
+
+	return &File{Owner: f.Owner, Config: config}, nil
+}
+
+// RemoveClaim deletes all config files written for claimUID below dir.
+func RemoveClaim(dir, claimUID string) error {
+	if !isPathElement(claimUID) {
+		return fmt.Errorf("invalid claim UID %q", claimUID)
+	}
+	return os.RemoveAll(filepath.Join(dir, claimUID))
+}
+
+// envKeySuffix maps a claim UID or device name, both of which are restricted
+// to lower case alphanumerics, '-' and '.', to a portable env var name suffix.
+func envKeySuffix(s string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z':
+			return r - 'a' + 'A'
+		case r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+			return r
+		}
+		return '_'
+	}, s)
+}
+
+func isPathElement(s string) bool {
+	return s != "" && s != "." && s != ".." && !strings.ContainsAny(s, `/\`)
+}
@@ -0,0 +1,71 @@
+package handoff
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	configapi "runtime-spec-dra-driver/api/v1alpha1"
+)
+
+func TestReadChecksOwner(t *testing.T) {
+	dir := t.TempDir()
+	ref := Ref{ClaimUID: "claim-uid", Device: "runtime-spec-0"}
+	config := &configapi.RuntimeSpecEditConfig{
+		FailurePolicy: configapi.FailurePolicyFailOpen,
+		Spec: &configapi.RuntimeSpec{
+			Linux: &configapi.Linux{
+				Resources: &configapi.LinuxResources{Unified: map[string]string{"memory.high": "1048576"}},
+			},
+		},
+	}
+	if _, err := Write(dir, ref, &File{Owner: Owner{Namespace: "team-a", PodUIDs: []string{"pod-a"}}, Config: config}); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+
+	f, err := Read(dir, ref)
+	if err != nil {
+		t.Fatalf("Read: %v", err)
+	}
+	if got := f.Config.Spec.Linux.Resources.Unified["memory.high"]; got != "1048576" {
+		t.Errorf("memory.high = %q, want 1048576", got)
+	}
+	if f.Config.MergeStrategy != configapi.DefaultMergeStrategy {
+		t.Errorf("merge strategy = %q, want the normalized default %q", f.Config.MergeStrategy, configapi.DefaultMergeStrategy)
+	}
+
+	tests := []struct {
+		name      string
+		namespace string
+		podUID    string
+		wantErr   bool
+	}{
+		{"reserved pod", "team-a", "pod-a", false},
+		{"other pod in namespace", "team-a", "pod-b", true},
+		{"other namespace", "team-b", "pod-a", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := f.Owner.Check(tt.namespace, tt.podUID)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Check(%q, %q) = %v, want error %v", tt.namespace, tt.podUID, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestReadRejectsFileWithoutOwner(t *testing.T) {
+	dir := t.TempDir()
+	ref := Ref{ClaimUID: "claim-uid", Device: "runtime-spec-0"}
+	// Files written by earlier versions hold the bare config.
+	legacy := `{"apiVersion":"dra.runtime-spec.io/v1alpha1","kind":"RuntimeSpecEditConfig","spec":{}}`
+	if err := os.MkdirAll(filepath.Dir(Path(dir, ref)), 0700); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(Path(dir, ref), []byte(legacy), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Read(dir, ref); err == nil {
+		t.Error("Read of a file without owner succeeded")
+	}
+}
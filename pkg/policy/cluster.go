@@ -0,0 +1,141 @@
+package policy
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/dynamic/dynamicinformer"
+	"k8s.io/client-go/informers"
+	coreclientset "k8s.io/client-go/kubernetes"
+	corelisters "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
+
+	configapi "runtime-spec-dra-driver/api/v1alpha1"
+)
+
+// RuntimeSpecPolicyResource is the resource served for RuntimeSpecPolicy objects.
+var RuntimeSpecPolicyResource = schema.GroupVersionResource{
+	Group:    configapi.GroupName,
+	Version:  configapi.Version,
+	Resource: configapi.RuntimeSpecPolicyResource,
+}
+
+// ClusterPolicies resolves the RuntimeSpecPolicy objects that apply to a
+// namespace from informer caches.
+type ClusterPolicies struct {
+	policies   cache.GenericLister
+	namespaces corelisters.NamespaceLister
+}
+
+// NewClusterPolicies starts informers for RuntimeSpecPolicy objects and
+// namespaces and waits for their caches to sync.
+func NewClusterPolicies(ctx context.Context, coreclient coreclientset.Interface, dynamicclient dynamic.Interface) (*ClusterPolicies, error) {
+	dynamicFactory := dynamicinformer.NewDynamicSharedInformerFactory(dynamicclient, 0)
+	policyInformer := dynamicFactory.ForResource(RuntimeSpecPolicyResource)
+
+	coreFactory := informers.NewSharedInformerFactory(coreclient, 0)
+	namespaceInformer := coreFactory.Core().V1().Namespaces()
+
+	// Touch the informers before starting the factories so they get registered.
+	policyInformer.Informer()
+	namespaceInformer.Informer()
+
+	dynamicFactory.Start(ctx.Done())
+	coreFactory.Start(ctx.Done())
+
+	for gvr, synced := range dynamicFactory.WaitForCacheSync(ctx.Done()) {
+		if !synced {
+			return nil, fmt.Errorf("unable to sync informer cache for %v", gvr)
+		}
+	}
+	for typ, synced := range coreFactory.WaitForCacheSync(ctx.Done()) {
+		if !synced {
+			return nil, fmt.Errorf("unable to sync informer cache for %v", typ)
+		}
+	}
+
+	return &ClusterPolicies{
+		policies:   policyInformer.Lister(),
+		namespaces: namespaceInformer.Lister(),
+	}, nil
+}
+
+// Check resolves the policies selecting namespace (in name order) and returns
+// the name of the first one that admits config. If no policy selects the
+// namespace, or none of them admits config, an error naming every policy that
+// denied it is returned.
+func (c *ClusterPolicies) Check(namespace string, config *configapi.RuntimeSpecEditConfig) (string, error) {
+	policies, err := c.forNamespace(namespace)
+	if err != nil {
+		return "", err
+	}
+	if len(policies) == 0 {
+		return "", fmt.Errorf("no RuntimeSpecPolicy applies to namespace %q", namespace)
+	}
+
+	var denials []string
+	for _, p := range policies {
+		rules := &Policy{RuntimeSpecPolicyRules: p.Spec.RuntimeSpecPolicyRules}
+		if err := rules.Validate(); err != nil {
+			denials = append(denials, fmt.Sprintf("RuntimeSpecPolicy %q is invalid: %v", p.Name, err))
+			continue
+		}
+		if err := rules.CheckConfig(config); err != nil {
+			denials = append(denials, fmt.Sprintf("RuntimeSpecPolicy %q: %v", p.Name, err))
+			continue
+		}
+		return p.Name, nil
+	}
+
+	return "", fmt.Errorf("denied by all RuntimeSpecPolicies for namespace %q: %s", namespace, strings.Join(denials, "; "))
+}
+
+// forNamespace returns the policies selecting namespace, sorted by name.
+func (c *ClusterPolicies) forNamespace(namespace string) ([]*configapi.RuntimeSpecPolicy, error) {
+	ns, err := c.namespaces.Get(namespace)
+	if err != nil {
+		return nil, fmt.Errorf("unable to get namespace %q: %w", namespace, err)
+	}
+
+	objs, err := c.policies.List(labels.Everything())
+	if err != nil {
+		return nil, fmt.Errorf("unable to list RuntimeSpecPolicies: %w", err)
+	}
+
+	var policies []*configapi.RuntimeSpecPolicy
+	for _, obj := range objs {
+		u, ok := obj.(*unstructured.Unstructured)
+		if !ok {
+			return nil, fmt.Errorf("unexpected object of type %T in RuntimeSpecPolicy cache", obj)
+		}
+		var p configapi.RuntimeSpecPolicy
+		if err := runtime.DefaultUnstructuredConverter.FromUnstructured(u.Object, &p); err != nil {
+			return nil, fmt.Errorf("unable to convert RuntimeSpecPolicy %q: %w", u.GetName(), err)
+		}
+
+		selector := labels.Everything()
+		if p.Spec.NamespaceSelector != nil {
+			selector, err = metav1.LabelSelectorAsSelector(p.Spec.NamespaceSelector)
+			if err != nil {
+				return nil, fmt.Errorf("invalid namespaceSelector in RuntimeSpecPolicy %q: %w", p.Name, err)
+			}
+		}
+		if selector.Matches(labels.Set(ns.Labels)) {
+			policies = append(policies, &p)
+		}
+	}
+
+	slices.SortFunc(policies, func(a, b *configapi.RuntimeSpecPolicy) int {
+		return strings.Compare(a.Name, b.Name)
+	})
+
+	return policies, nil
+}
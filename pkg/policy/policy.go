@@ -0,0 +1,521 @@
+package policy
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"sigs.k8s.io/yaml"
+
+	configapi "runtime-spec-dra-driver/api/v1alpha1"
+)
+
+// SafeSysctls are the namespaced sysctls any claim may set. They are isolated
+// from the host and other pods and cannot be used to exhaust node resources.
+// Policies may allow further sysctls through AllowedSysctls.
+var SafeSysctls = []string{
+	"kernel.shm_rmid_forced",
+	"net.core.somaxconn",
+	"net.ipv4.ip_local_port_range",
+	"net.ipv4.ip_local_reserved_ports",
+	"net.ipv4.ip_unprivileged_port_start",
+	"net.ipv4.ping_group_range",
+	"net.ipv4.tcp_fin_timeout",
+	"net.ipv4.tcp_keepalive_intvl",
+	"net.ipv4.tcp_keepalive_probes",
+	"net.ipv4.tcp_keepalive_time",
+	"net.ipv4.tcp_syncookies",
+}
+
+// Policy restricts which parts of a RuntimeSpec a claim may set. A node
+// policy is loaded from a local file by both the kubelet plugin and the NRI
+// plugin so that a spec is checked when the claim is prepared and again when
+// the container is created. See configapi.RuntimeSpecPolicyRules for the
+// meaning of each rule.
+type Policy struct {
+	configapi.RuntimeSpecPolicyRules `json:",inline"`
+}
+
+// Load reads a policy from a YAML or JSON file. Unknown fields are rejected so
+// that typos in the policy do not silently widen what claims may do.
+func Load(path string) (*Policy, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read policy file: %w", err)
+	}
+
+	var policy Policy
+	if err := yaml.UnmarshalStrict(data, &policy); err != nil {
+		return nil, fmt.Errorf("unable to parse policy file %s: %w", path, err)
+	}
+	if err := policy.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid policy file %s: %w", path, err)
+	}
+	return &policy, nil
+}
+
+// removalsPath is the field path prefix removals are checked as.
+const removalsPath = "remove"
+
+// Validate returns an error listing the rules of the policy that cannot be
+// enforced, so that they are reported when the policy is loaded instead of
+// denying every claim.
+func (p *Policy) Validate() error {
+	var allErrs field.ErrorList
+	for i, r := range p.Unified {
+		idxPath := field.NewPath("unified").Index(i)
+		if (r.Min != nil || r.Max != nil) && !rangeSupported(r.Key) {
+			allErrs = append(allErrs, field.Invalid(idxPath.Child("key"), r.Key, "min and max are only supported for numeric keys, io.max, io.weight and cpu.max"))
+		}
+		if r.Min != nil && r.Max != nil && *r.Min > *r.Max {
+			allErrs = append(allErrs, field.Invalid(idxPath.Child("min"), *r.Min, "must not exceed max"))
+		}
+	}
+	return allErrs.ToAggregate()
+}
+
+// CheckConfig returns an error listing every part of config that the policy
+// denies: everything Check denies in its spec, and removals and a merge
+// strategy that may loosen the container's limits unless the policy allows
+// them.
+func (p *Policy) CheckConfig(config *configapi.RuntimeSpecEditConfig) error {
+	if p == nil {
+		p = &Policy{}
+	}
+
+	allErrs, err := p.checkSpec(config.SpecForPolicy())
+	if err != nil {
+		return err
+	}
+
+	allErrs = append(allErrs, p.checkRemovals(config.Remove)...)
+
+	strategy := config.MergeStrategy
+	if strategy == "" {
+		strategy = configapi.DefaultMergeStrategy
+	}
+	if !p.mergeStrategyAllowed(strategy) {
+		allErrs = append(allErrs, field.Forbidden(field.NewPath("mergeStrategy"), fmt.Sprintf("merge strategy %q is not allowed by policy", strategy)))
+	}
+
+	return allErrs.ToAggregate()
+}
+
+// Check returns an error listing every part of spec that the policy denies.
+// A nil policy allows everything except sysctls outside of SafeSysctls,
+// namespaces and seccomp profiles.
+func (p *Policy) Check(spec *configapi.RuntimeSpec) error {
+	if p == nil {
+		p = &Policy{}
+	}
+	allErrs, err := p.checkSpec(spec)
+	if err != nil {
+		return err
+	}
+	return allErrs.ToAggregate()
+}
+
+func (p *Policy) checkSpec(spec *configapi.RuntimeSpec) (field.ErrorList, error) {
+	if spec == nil {
+		return nil, nil
+	}
+
+	fldPath := field.NewPath("spec")
+
+	var allErrs field.ErrorList
+	fields, err := setFields(spec)
+	if err != nil {
+		return nil, err
+	}
+	for _, f := range fields {
+		if !p.fieldAllowed(f) {
+			allErrs = append(allErrs, field.Forbidden(fldPath.Child(f), "field is not allowed by policy"))
+		}
+	}
+
+	if spec.Hooks != nil && len(p.AllowedHookPaths) > 0 {
+		allErrs = append(allErrs, p.checkHooks(spec.Hooks, fldPath.Child("hooks"))...)
+	}
+
+	if len(p.AllowedMountSources) > 0 {
+		for i, m := range spec.Mounts {
+			sourcePath := fldPath.Child("mounts").Index(i).Child("source")
+			switch {
+			case m.IsBind() && !filepath.IsAbs(m.Source):
+				allErrs = append(allErrs, field.Forbidden(sourcePath, fmt.Sprintf("bind mount source %q is not an absolute path", m.Source)))
+			case filepath.IsAbs(m.Source) && !underAny(filepath.Clean(m.Source), p.AllowedMountSources):
+				allErrs = append(allErrs, field.Forbidden(sourcePath, fmt.Sprintf("mount source %q is not allowed by policy", m.Source)))
+			}
+		}
+	}
+
+	if spec.Linux != nil {
+		for _, name := range slices.Sorted(maps.Keys(spec.Linux.Sysctl)) {
+			if !p.sysctlAllowed(name) {
+				allErrs = append(allErrs, field.Forbidden(fldPath.Child("linux", "sysctl").Key(name), fmt.Sprintf("sysctl %q is not allowed by policy", name)))
+			}
+		}
+	}
+
+	if spec.Linux != nil {
+		for i, ns := range spec.Linux.Namespaces {
+			if !slices.Contains(p.AllowedNamespaces, ns.Type) {
+				allErrs = append(allErrs, field.Forbidden(fldPath.Child("linux", "namespaces").Index(i), fmt.Sprintf("%s namespace is not allowed by policy", ns.Type)))
+			}
+		}
+	}
+
+	if profile, ok := spec.Annotations[configapi.SeccompProfileAnnotation]; ok && !matchesPattern(profile, p.AllowedSeccompProfiles) {
+		allErrs = append(allErrs, field.Forbidden(fldPath.Child("annotations").Key(configapi.SeccompProfileAnnotation), fmt.Sprintf("seccomp profile %q is not allowed by policy", profile)))
+	}
+	if spec.Linux != nil && spec.Linux.Seccomp != nil && !p.AllowInlineSeccompProfiles {
+		allErrs = append(allErrs, field.Forbidden(fldPath.Child("linux", "seccomp"), "inline seccomp profiles are not allowed by policy"))
+	}
+
+	if spec.Linux != nil && spec.Linux.Resources != nil && len(p.Unified) > 0 {
+		// Check blockIO, cpu burst and idle and the resource limits as the
+		// unified values they are applied as, so that they cannot be used to
+		// bypass the unified rules.
+		resPath := fldPath.Child("linux", "resources")
+		unified, err := spec.Linux.Resources.EffectiveUnified()
+		if err != nil {
+			allErrs = append(allErrs, field.Invalid(resPath, field.OmitValueType{}, err.Error()))
+		} else {
+			allErrs = append(allErrs, p.checkUnified(unified, resPath.Child("unified"))...)
+		}
+		allErrs = append(allErrs, p.checkResourceLimits(spec.Linux.Resources, resPath)...)
+	}
+
+	return allErrs, nil
+}
+
+// fieldAllowed reports whether the dotted spec path f may be set. Allowed
+// removal paths do not restrict the spec.
+func (p *Policy) fieldAllowed(f string) bool {
+	if matchesAny(f, p.DeniedFields) {
+		return false
+	}
+	allowed := slices.DeleteFunc(slices.Clone(p.AllowedFields), func(a string) bool {
+		return matchesAny(a, []string{removalsPath})
+	})
+	if len(allowed) == 0 {
+		return true
+	}
+	return matchesAny(f, allowed)
+}
+
+// checkRemovals returns an error for every kind of setting remove strips from
+// the container. Removals can take away mounts, env and devices the kubelet
+// provides, such as the service account token, so each kind is checked as the
+// path remove.<kind> and denied unless AllowedFields covers it.
+func (p *Policy) checkRemovals(remove *configapi.Removals) field.ErrorList {
+	var allErrs field.ErrorList
+	if remove == nil {
+		return allErrs
+	}
+	for _, r := range []struct {
+		name  string
+		items []string
+	}{
+		{"env", remove.Env},
+		{"mounts", remove.Mounts},
+		{"devices", remove.Devices},
+		{"annotations", remove.Annotations},
+	} {
+		path := removalsPath + "." + r.name
+		if len(r.items) > 0 && (matchesAny(path, p.DeniedFields) || !matchesAny(path, p.AllowedFields)) {
+			allErrs = append(allErrs, field.Forbidden(field.NewPath(removalsPath, r.name), fmt.Sprintf("removing %s is not allowed by policy", r.name)))
+		}
+	}
+	return allErrs
+}
+
+// mergeStrategyAllowed reports whether a config may use strategy. Strategies
+// that never loosen the container's limits are always allowed.
+func (p *Policy) mergeStrategyAllowed(strategy configapi.MergeStrategy) bool {
+	switch strategy {
+	case configapi.MergeStrategyOnlyTighten, configapi.MergeStrategyFailOnConflict:
+		return true
+	}
+	return slices.Contains(p.AllowedMergeStrategies, strategy)
+}
+
+// sysctlAllowed reports whether the sysctl name may be set.
+func (p *Policy) sysctlAllowed(name string) bool {
+	return slices.Contains(SafeSysctls, name) || matchesPattern(name, p.AllowedSysctls)
+}
+
+// matchesPattern reports whether name equals any of patterns, or starts with
+// the prefix of a pattern ending in "*".
+func matchesPattern(name string, patterns []string) bool {
+	for _, pattern := range patterns {
+		if prefix, ok := strings.CutSuffix(pattern, "*"); ok && strings.HasPrefix(name, prefix) {
+			return true
+		}
+		if pattern == name {
+			return true
+		}
+	}
+	return false
+}
+
+func (p *Policy) checkHooks(hooks *configapi.Hooks, fldPath *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+	stages := []struct {
+		name  string
+		hooks []configapi.Hook
+	}{
+		{"prestart", hooks.Prestart},
+		{"createRuntime", hooks.CreateRuntime},
+		{"createContainer", hooks.CreateContainer},
+		{"startContainer", hooks.StartContainer},
+		{"poststart", hooks.Poststart},
+		{"poststop", hooks.Poststop},
+	}
+	for _, stage := range stages {
+		for i, h := range stage.hooks {
+			if !slices.Contains(p.AllowedHookPaths, filepath.Clean(h.Path)) {
+				allErrs = append(allErrs, field.Forbidden(fldPath.Child(stage.name).Index(i).Child("path"), fmt.Sprintf("hook %q is not allowed by policy", h.Path)))
+			}
+		}
+	}
+	return allErrs
+}
+
+func (p *Policy) checkUnified(unified map[string]string, fldPath *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+	for _, key := range slices.Sorted(maps.Keys(unified)) {
+		idx := slices.IndexFunc(p.Unified, func(r configapi.UnifiedRule) bool { return r.Key == key })
+		if idx < 0 {
+			allErrs = append(allErrs, field.Forbidden(fldPath.Key(key), "unified key is not allowed by policy"))
+			continue
+		}
+		if err := checkUnifiedRule(p.Unified[idx], key, unified[key]); err != nil {
+			allErrs = append(allErrs, field.Forbidden(fldPath.Key(key), err.Error()))
+		}
+	}
+	return allErrs
+}
+
+// checkResourceLimits checks the memory, cpu, pids and hugepage limits of res
+// as the unified values they are applied as on cgroup v2, so that they cannot
+// be used to exceed the range of a unified rule. Limits without a rule for
+// their key are left to the field rules.
+func (p *Policy) checkResourceLimits(res *configapi.LinuxResources, fldPath *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+	check := func(path *field.Path, key, value string) {
+		idx := slices.IndexFunc(p.Unified, func(r configapi.UnifiedRule) bool { return r.Key == key })
+		if idx < 0 {
+			return
+		}
+		if err := checkUnifiedRule(p.Unified[idx], key, value); err != nil {
+			allErrs = append(allErrs, field.Forbidden(path, fmt.Sprintf("applied as %s: %v", key, err)))
+		}
+	}
+
+	if mem := res.Memory; mem != nil {
+		memPath := fldPath.Child("memory")
+		if mem.Limit != nil {
+			check(memPath.Child("limit"), "memory.max", unifiedLimit(*mem.Limit))
+		}
+		if mem.Reservation != nil && *mem.Reservation >= 0 {
+			check(memPath.Child("reservation"), "memory.low", strconv.FormatInt(*mem.Reservation, 10))
+		}
+		if mem.Swap != nil {
+			// The swap limit covers memory and swap, as runc converts it.
+			// Without a memory limit the whole value is a swap bound.
+			swap := *mem.Swap
+			if swap > 0 && mem.Limit != nil && *mem.Limit > 0 {
+				swap = max(swap-*mem.Limit, 0)
+			}
+			check(memPath.Child("swap"), "memory.swap.max", unifiedLimit(swap))
+		}
+	}
+
+	if cpu := res.CPU; cpu != nil {
+		cpuPath := fldPath.Child("cpu")
+		if cpu.Shares != nil {
+			check(cpuPath.Child("shares"), "cpu.weight", strconv.FormatUint(configapi.CPUSharesToWeight(*cpu.Shares), 10))
+		}
+		if cpu.Quota != nil {
+			period := uint64(100000)
+			if cpu.Period != nil {
+				period = *cpu.Period
+			}
+			check(cpuPath.Child("quota"), "cpu.max", unifiedLimit(*cpu.Quota)+" "+strconv.FormatUint(period, 10))
+		}
+	}
+
+	if res.Pids != nil {
+		check(fldPath.Child("pids", "limit"), "pids.max", unifiedLimit(res.Pids.Limit))
+	}
+
+	for i, hp := range res.HugepageLimits {
+		check(fldPath.Child("hugepageLimits").Index(i), "hugetlb."+hp.Pagesize+".max", strconv.FormatUint(hp.Limit, 10))
+	}
+
+	return allErrs
+}
+
+// unifiedLimit formats a cgroup v1 style limit as a unified value, mapping
+// zero or less to "max".
+func unifiedLimit(limit int64) string {
+	if limit <= 0 {
+		return "max"
+	}
+	return strconv.FormatInt(limit, 10)
+}
+
+// rangeKeys are the unified keys holding a single number or "max" that rules
+// may restrict to a range, besides hugetlb.<size>.max and the keys handled by
+// rangeValues.
+var rangeKeys = []string{
+	"cpu.idle",
+	"cpu.max.burst",
+	"cpu.weight",
+	"memory.high",
+	"memory.low",
+	"memory.max",
+	"memory.min",
+	"memory.swap.high",
+	"memory.swap.max",
+	"memory.zswap.max",
+	"pids.max",
+}
+
+// rangeSupported reports whether a rule for the unified key may set min and
+// max.
+func rangeSupported(key string) bool {
+	switch key {
+	case "io.max", "io.weight", "cpu.max":
+		return true
+	}
+	if size, ok := strings.CutPrefix(key, "hugetlb."); ok {
+		size, ok = strings.CutSuffix(size, ".max")
+		return ok && !strings.Contains(size, ".")
+	}
+	return slices.Contains(rangeKeys, key)
+}
+
+// rangeValues returns the values in value that a rule's range applies to:
+// each rbps/wbps/riops/wiops limit of io.max, each weight of io.weight, the
+// quota of cpu.max and the whole value of other keys.
+func rangeValues(key, value string) []string {
+	var values []string
+	switch key {
+	case "io.max":
+		for _, f := range strings.Fields(value) {
+			if _, v, ok := strings.Cut(f, "="); ok {
+				values = append(values, v)
+			}
+		}
+	case "io.weight":
+		// Lines are "default <weight>", "<major>:<minor> <weight>" or a
+		// bare "<weight>".
+		for _, line := range strings.Split(value, "\n") {
+			if fields := strings.Fields(line); len(fields) > 0 {
+				values = append(values, fields[len(fields)-1])
+			}
+		}
+	case "cpu.max":
+		// "<quota> [<period>]"; only the quota is a limit.
+		if fields := strings.Fields(value); len(fields) > 0 {
+			values = append(values, fields[0])
+		}
+	default:
+		values = append(values, strings.TrimSpace(value))
+	}
+	return values
+}
+
+// checkUnifiedRule verifies that every numeric limit in value lies within the
+// rule's range, see rangeValues. "max" is unbounded: it passes a minimum and
+// exceeds any maximum.
+func checkUnifiedRule(r configapi.UnifiedRule, key, value string) error {
+	if r.Min == nil && r.Max == nil {
+		return nil
+	}
+	if !rangeSupported(key) {
+		return fmt.Errorf("policy sets a range for %s, which has no numeric value to check", key)
+	}
+
+	for _, limit := range rangeValues(key, value) {
+		if limit == "max" {
+			if r.Max != nil {
+				return fmt.Errorf("value %q exceeds the maximum of %d allowed by policy", limit, *r.Max)
+			}
+			continue
+		}
+		n, err := strconv.ParseUint(limit, 10, 64)
+		if err != nil {
+			return fmt.Errorf("value %q is not a number and cannot be checked against policy", limit)
+		}
+		if r.Min != nil && n < *r.Min {
+			return fmt.Errorf("value %d is below the minimum of %d allowed by policy", n, *r.Min)
+		}
+		if r.Max != nil && n > *r.Max {
+			return fmt.Errorf("value %d exceeds the maximum of %d allowed by policy", n, *r.Max)
+		}
+	}
+	return nil
+}
+
+// setFields returns the dotted paths of all fields set in spec. Objects are
+// descended into; any other value (including lists) is reported as a whole.
+func setFields(spec *configapi.RuntimeSpec) ([]string, error) {
+	data, err := json.Marshal(spec)
+	if err != nil {
+		return nil, fmt.Errorf("unable to encode runtime spec: %w", err)
+	}
+	var obj map[string]any
+	if err := json.Unmarshal(data, &obj); err != nil {
+		return nil, fmt.Errorf("unable to decode runtime spec: %w", err)
+	}
+
+	var fields []string
+	var walk func(prefix string, obj map[string]any)
+	walk = func(prefix string, obj map[string]any) {
+		for _, k := range slices.Sorted(maps.Keys(obj)) {
+			path := k
+			if prefix != "" {
+				path = prefix + "." + k
+			}
+			if child, ok := obj[k].(map[string]any); ok {
+				walk(path, child)
+				continue
+			}
+			fields = append(fields, path)
+		}
+	}
+	walk("", obj)
+
+	return fields, nil
+}
+
+// matchesAny reports whether path equals, or is nested below, any of patterns.
+func matchesAny(path string, patterns []string) bool {
+	for _, p := range patterns {
+		if path == p || strings.HasPrefix(path, p+".") {
+			return true
+		}
+	}
+	return false
+}
+
+// underAny reports whether path equals, or is located below, any of dirs.
+func underAny(path string, dirs []string) bool {
+	path = filepath.Clean(path)
+	for _, d := range dirs {
+		d = filepath.Clean(d)
+		if path == d || strings.HasPrefix(path, d+string(filepath.Separator)) || d == string(filepath.Separator) {
+			return true
+		}
+	}
+	return false
+}
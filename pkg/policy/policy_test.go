@@ -0,0 +1,199 @@
+package policy
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"k8s.io/utils/ptr"
+
+	configapi "runtime-spec-dra-driver/api/v1alpha1"
+)
+
+// unifiedSpec returns a spec setting the unified parameters.
+func unifiedSpec(unified map[string]string) *configapi.RuntimeSpec {
+	return &configapi.RuntimeSpec{
+		Linux: &configapi.Linux{Resources: &configapi.LinuxResources{Unified: unified}},
+	}
+}
+
+func TestCheckUnifiedRanges(t *testing.T) {
+	tests := []struct {
+		name    string
+		rule    configapi.UnifiedRule
+		value   string
+		wantErr bool
+	}{
+		{"number within range", configapi.UnifiedRule{Key: "memory.high", Min: ptr.To[uint64](1024), Max: ptr.To[uint64](4096)}, "2048", false},
+		{"number below minimum", configapi.UnifiedRule{Key: "memory.high", Min: ptr.To[uint64](1024)}, "512", true},
+		{"max passes a minimum", configapi.UnifiedRule{Key: "memory.high", Min: ptr.To[uint64](1024)}, "max", false},
+		{"max exceeds a maximum", configapi.UnifiedRule{Key: "memory.high", Max: ptr.To[uint64](4096)}, "max", true},
+		{"cpu.max quota with period", configapi.UnifiedRule{Key: "cpu.max", Max: ptr.To[uint64](100000)}, "50000 100000", false},
+		{"cpu.max quota above maximum", configapi.UnifiedRule{Key: "cpu.max", Max: ptr.To[uint64](100000)}, "200000 100000", true},
+		{"cpu.max unlimited", configapi.UnifiedRule{Key: "cpu.max", Max: ptr.To[uint64](100000)}, "max 100000", true},
+		{"cpu.max.burst", configapi.UnifiedRule{Key: "cpu.max.burst", Max: ptr.To[uint64](10000)}, "5000", false},
+		{"io.weight default", configapi.UnifiedRule{Key: "io.weight", Min: ptr.To[uint64](50), Max: ptr.To[uint64](200)}, "default 100", false},
+		{"io.weight device above maximum", configapi.UnifiedRule{Key: "io.weight", Max: ptr.To[uint64](200)}, "8:0 500", true},
+		{"io.weight bare weight", configapi.UnifiedRule{Key: "io.weight", Max: ptr.To[uint64](200)}, "100", false},
+		{"io.max limits", configapi.UnifiedRule{Key: "io.max", Max: ptr.To[uint64](1048576)}, "8:0 rbps=1048576 wiops=100", false},
+		{"io.max limit above maximum", configapi.UnifiedRule{Key: "io.max", Max: ptr.To[uint64](1048576)}, "8:0 rbps=2097152", true},
+		{"hugetlb limit", configapi.UnifiedRule{Key: "hugetlb.2MB.max", Max: ptr.To[uint64](1 << 30)}, "1073741824", false},
+		{"no range", configapi.UnifiedRule{Key: "cpuset.cpus"}, "0-3", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := &Policy{RuntimeSpecPolicyRules: configapi.RuntimeSpecPolicyRules{Unified: []configapi.UnifiedRule{tt.rule}}}
+			if err := p.Validate(); err != nil {
+				t.Fatalf("Validate: %v", err)
+			}
+			err := p.Check(unifiedSpec(map[string]string{tt.rule.Key: tt.value}))
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Check(%s=%q) = %v, want error %v", tt.rule.Key, tt.value, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestLoadRejectsUnsupportedRange(t *testing.T) {
+	tests := []struct {
+		name    string
+		policy  string
+		wantErr bool
+	}{
+		{"numeric key", "unified:\n- key: memory.high\n  max: 1024\n", false},
+		{"key without range", "unified:\n- key: cpuset.cpus\n", false},
+		{"range on non-numeric key", "unified:\n- key: cpuset.cpus\n  max: 4\n", true},
+		{"range on unknown key", "unified:\n- key: memory.oom.group\n  min: 1\n", true},
+		{"min above max", "unified:\n- key: pids.max\n  min: 100\n  max: 10\n", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "policy.yaml")
+			if err := os.WriteFile(path, []byte(tt.policy), 0600); err != nil {
+				t.Fatal(err)
+			}
+			_, err := Load(path)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Load() = %v, want error %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestCheckResourceLimits(t *testing.T) {
+	tests := []struct {
+		name      string
+		rule      configapi.UnifiedRule
+		resources *configapi.LinuxResources
+		wantErr   bool
+	}{
+		{
+			name:      "memory limit within memory.max",
+			rule:      configapi.UnifiedRule{Key: "memory.max", Max: ptr.To[uint64](1 << 30)},
+			resources: &configapi.LinuxResources{Memory: &configapi.LinuxMemory{Limit: ptr.To[int64](1 << 29)}},
+		},
+		{
+			name:      "memory limit above memory.max",
+			rule:      configapi.UnifiedRule{Key: "memory.max", Max: ptr.To[uint64](1 << 30)},
+			resources: &configapi.LinuxResources{Memory: &configapi.LinuxMemory{Limit: ptr.To[int64](1 << 31)}},
+			wantErr:   true,
+		},
+		{
+			name:      "unlimited memory limit",
+			rule:      configapi.UnifiedRule{Key: "memory.max", Max: ptr.To[uint64](1 << 30)},
+			resources: &configapi.LinuxResources{Memory: &configapi.LinuxMemory{Limit: ptr.To[int64](-1)}},
+			wantErr:   true,
+		},
+		{
+			name:      "memory reservation below memory.low",
+			rule:      configapi.UnifiedRule{Key: "memory.low", Min: ptr.To[uint64](1 << 20)},
+			resources: &configapi.LinuxResources{Memory: &configapi.LinuxMemory{Reservation: ptr.To[int64](1 << 10)}},
+			wantErr:   true,
+		},
+		{
+			name: "swap beyond the memory limit within memory.swap.max",
+			rule: configapi.UnifiedRule{Key: "memory.swap.max", Max: ptr.To[uint64](1 << 30)},
+			resources: &configapi.LinuxResources{Memory: &configapi.LinuxMemory{
+				Limit: ptr.To[int64](1 << 31), Swap: ptr.To[int64](1<<31 + 1<<29),
+			}},
+		},
+		{
+			name:      "swap above memory.swap.max",
+			rule:      configapi.UnifiedRule{Key: "memory.swap.max", Max: ptr.To[uint64](1 << 30)},
+			resources: &configapi.LinuxResources{Memory: &configapi.LinuxMemory{Swap: ptr.To[int64](1 << 31)}},
+			wantErr:   true,
+		},
+		{
+			name:      "unlimited swap",
+			rule:      configapi.UnifiedRule{Key: "memory.swap.max", Max: ptr.To[uint64](1 << 30)},
+			resources: &configapi.LinuxResources{Memory: &configapi.LinuxMemory{Swap: ptr.To[int64](-1)}},
+			wantErr:   true,
+		},
+		{
+			name:      "cpu shares above cpu.weight",
+			rule:      configapi.UnifiedRule{Key: "cpu.weight", Max: ptr.To[uint64](100)},
+			resources: &configapi.LinuxResources{CPU: &configapi.LinuxCPU{Shares: ptr.To[uint64](262144)}},
+			wantErr:   true,
+		},
+		{
+			name:      "cpu shares within cpu.weight",
+			rule:      configapi.UnifiedRule{Key: "cpu.weight", Max: ptr.To[uint64](100)},
+			resources: &configapi.LinuxResources{CPU: &configapi.LinuxCPU{Shares: ptr.To[uint64](1024)}},
+		},
+		{
+			name:      "cpu quota within cpu.max",
+			rule:      configapi.UnifiedRule{Key: "cpu.max", Max: ptr.To[uint64](100000)},
+			resources: &configapi.LinuxResources{CPU: &configapi.LinuxCPU{Quota: ptr.To[int64](50000), Period: ptr.To[uint64](100000)}},
+		},
+		{
+			name:      "cpu quota above cpu.max",
+			rule:      configapi.UnifiedRule{Key: "cpu.max", Max: ptr.To[uint64](100000)},
+			resources: &configapi.LinuxResources{CPU: &configapi.LinuxCPU{Quota: ptr.To[int64](400000)}},
+			wantErr:   true,
+		},
+		{
+			name:      "unlimited cpu quota",
+			rule:      configapi.UnifiedRule{Key: "cpu.max", Max: ptr.To[uint64](100000)},
+			resources: &configapi.LinuxResources{CPU: &configapi.LinuxCPU{Quota: ptr.To[int64](-1)}},
+			wantErr:   true,
+		},
+		{
+			name:      "pids limit within pids.max",
+			rule:      configapi.UnifiedRule{Key: "pids.max", Max: ptr.To[uint64](100)},
+			resources: &configapi.LinuxResources{Pids: &configapi.LinuxPids{Limit: 50}},
+		},
+		{
+			name:      "pids limit above pids.max",
+			rule:      configapi.UnifiedRule{Key: "pids.max", Max: ptr.To[uint64](100)},
+			resources: &configapi.LinuxResources{Pids: &configapi.LinuxPids{Limit: 1000}},
+			wantErr:   true,
+		},
+		{
+			name:      "unlimited pids limit",
+			rule:      configapi.UnifiedRule{Key: "pids.max", Max: ptr.To[uint64](100)},
+			resources: &configapi.LinuxResources{Pids: &configapi.LinuxPids{Limit: -1}},
+			wantErr:   true,
+		},
+		{
+			name:      "hugepage limit above hugetlb max",
+			rule:      configapi.UnifiedRule{Key: "hugetlb.2MB.max", Max: ptr.To[uint64](1 << 30)},
+			resources: &configapi.LinuxResources{HugepageLimits: []configapi.LinuxHugepageLimit{{Pagesize: "2MB", Limit: 1 << 31}}},
+			wantErr:   true,
+		},
+		{
+			name:      "limit without a rule for its key",
+			rule:      configapi.UnifiedRule{Key: "memory.high", Max: ptr.To[uint64](1 << 30)},
+			resources: &configapi.LinuxResources{Memory: &configapi.LinuxMemory{Limit: ptr.To[int64](1 << 31)}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := &Policy{RuntimeSpecPolicyRules: configapi.RuntimeSpecPolicyRules{Unified: []configapi.UnifiedRule{tt.rule}}}
+			spec := &configapi.RuntimeSpec{Linux: &configapi.Linux{Resources: tt.resources}}
+			err := p.Check(spec)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Check() = %v, want error %v", err, tt.wantErr)
+			}
+		})
+	}
+}